@@ -1,40 +1,45 @@
 package apu
 
 import (
-	"log"
-
 	"github.com/deybismelendez/liteboy/bus"
-	"github.com/hajimehoshi/ebiten/v2/audio"
 )
 
 const (
-	sampleRate = 44100
+	SampleRate = 44100
+	// Frecuencia del reloj de la CPU en t-ciclos por segundo
+	cpuClock = 4194304
 )
 
-var ctx *audio.Context = audio.NewContext(sampleRate)
-
 type APU struct {
-	audio  *audio.Context
-	bus    *bus.Bus
-	chan1  *SquareChannel
-	chan2  *SquareChannel
-	chan3  *WaveChannel
-	chan4  *NoiseChannel
-	player *audio.Player
-	reader *Reader
+	bus   *bus.Bus
+	chan1 *SquareChannel
+	chan2 *SquareChannel
+	chan3 *WaveChannel
+	chan4 *NoiseChannel
+	mixer *Mixer
+	sink  AudioSink
+	// Acumulador para convertir t-ciclos emulados en muestras
+	sampleCounter int
+	// T-ciclos emulados desde que se creó la APU
+	cycles uint64
+	// Grabación de escrituras en los registros de sonido, nil si no hay
+	vgm *VGM
+	// Transcripción de notas a MIDI, nil si no hay
+	midi *MIDI
 }
 
-func NewAPU(bus *bus.Bus) *APU {
+// NewAPU crea la APU enviando su salida al sink indicado; si sink es nil
+// las muestras se descartan
+func NewAPU(bus *bus.Bus, sink AudioSink) *APU {
 	ch1 := &SquareChannel{}
 	ch2 := &SquareChannel{}
 	ch3 := &WaveChannel{bus: bus}
 	ch4 := &NoiseChannel{lfsr: 0x7FFF}
-	reader := &Reader{ch1: ch1, ch2: ch2, ch3: ch3, ch4: ch4}
-
-	player, err := ctx.NewPlayer(reader)
-	if err != nil {
-		log.Fatal("error al crear audio player canal 1:", err)
+	mixer := &Mixer{ch1: ch1, ch2: ch2, ch3: ch3, ch4: ch4}
+	if sink == nil {
+		sink = NullSink{}
 	}
+
 	// Inicializar waveform RAM con patrón 00 FF 00 FF ...
 	for i := uint16(0); i < 0x10; i++ {
 		var addr uint16 = 0xFF30 + i
@@ -45,19 +50,42 @@ func NewAPU(bus *bus.Bus) *APU {
 		}
 	}
 
-	player.Play()
-
-	return &APU{
-		audio:  ctx,
-		bus:    bus,
-		chan1:  ch1,
-		chan2:  ch2,
-		chan3:  ch3,
-		chan4:  ch4,
-		reader: reader,
-		player: player,
+	apu := &APU{
+		bus:   bus,
+		chan1: ch1,
+		chan2: ch2,
+		chan3: ch3,
+		chan4: ch4,
+		mixer: mixer,
+		sink:  sink,
 	}
+	bus.OnSoundWrite = apu.onSoundWrite
+	return apu
+}
 
+// onSoundWrite recibe las escrituras de la CPU en 0xFF10-0xFF3F antes de
+// que lleguen al registro y las pasa a las grabaciones activas
+func (apu *APU) onSoundWrite(addr uint16, value byte) {
+	if apu.vgm != nil {
+		apu.vgm.wait(apu.cycles)
+		apu.vgm.write(addr, value)
+	}
+	if apu.midi != nil {
+		apu.midi.onWrite(apu, addr, value)
+	}
+}
+
+// SetSink cambia el destino de las muestras de audio
+func (apu *APU) SetSink(sink AudioSink) {
+	if sink == nil {
+		sink = NullSink{}
+	}
+	apu.sink = sink
+}
+
+// Sink devuelve el destino actual de las muestras de audio
+func (apu *APU) Sink() AudioSink {
+	return apu.sink
 }
 
 func (apu *APU) Step() {
@@ -83,8 +111,24 @@ func (apu *APU) Step() {
 	apu.bus.Write(0xFF26, 0x80|status) // Bit 7 siempre en 1 si APU está encendido
 	nr50 := apu.bus.Read(0xFF24)
 
-	apu.reader.leftVolume = float64((nr50>>4)&0x07) / 7.0
-	apu.reader.rightVolume = float64(nr50&0x07) / 7.0
+	apu.mixer.leftVolume = float64((nr50>>4)&0x07) / 7.0
+	apu.mixer.rightVolume = float64(nr50&0x07) / 7.0
+
+	if apu.midi != nil {
+		apu.midi.poll(apu)
+	}
+
+	// Genera las muestras correspondientes a los 4 t-ciclos emulados
+	apu.cycles += 4
+	apu.sampleCounter += 4 * SampleRate
+	for apu.sampleCounter >= cpuClock {
+		apu.sampleCounter -= cpuClock
+		left, right := apu.mixer.Sample()
+		if cs, ok := apu.sink.(ChannelSink); ok {
+			cs.WriteChannelSamples(apu.mixer.channels)
+		}
+		apu.sink.WriteSample(left, right)
+	}
 }
 
 func (apu *APU) updateChannel1() {
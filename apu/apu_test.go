@@ -0,0 +1,50 @@
+package apu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func TestBufferSinkCollectsOneFrameOfSamples(t *testing.T) {
+	sink := NewBufferSink()
+	apu := NewAPU(bus.NewBus(nil), sink)
+
+	// Un frame son 70224 t-ciclos y la APU avanza 4 por paso
+	for range 70224 / 4 {
+		apu.Step()
+	}
+
+	frames := len(sink.Drain()) / 2
+	want := SampleRate * 70224 / cpuClock
+	if frames < want || frames > want+1 {
+		t.Fatalf("muestras por frame = %d, se esperaban %d", frames, want)
+	}
+	if len(sink.Samples()) != 0 {
+		t.Fatal("Drain no vació el buffer")
+	}
+}
+
+func TestMultipleAPUsAreIndependent(t *testing.T) {
+	first := NewBufferSink()
+	second := NewBufferSink()
+	a := NewAPU(bus.NewBus(nil), first)
+	b := NewAPU(bus.NewBus(nil), second)
+
+	for range 1000 {
+		a.Step()
+	}
+	b.Step()
+
+	if len(first.Samples()) <= len(second.Samples()) {
+		t.Fatalf("los sinks no son independientes: %d vs %d", len(first.Samples()), len(second.Samples()))
+	}
+}
+
+func TestNilSinkDiscardsSamples(t *testing.T) {
+	apu := NewAPU(bus.NewBus(nil), nil)
+	apu.Step()
+	if _, ok := apu.Sink().(NullSink); !ok {
+		t.Fatalf("sink por defecto = %T, se esperaba NullSink", apu.Sink())
+	}
+}
@@ -65,7 +65,7 @@ type SquareChannel struct {
 }
 
 func (c *SquareChannel) GetSample() int {
-	freqRatio := c.frequency / sampleRate
+	freqRatio := c.frequency / SampleRate
 	var sample int = 0
 
 	if c.enabled && c.volume > 0 {
@@ -102,7 +102,7 @@ func (c *WaveChannel) GetSample() int {
 	}
 
 	// Avance de fase correcto escalando por 32 muestras
-	delta := c.frequency * 32.0 / sampleRate
+	delta := c.frequency * 32.0 / SampleRate
 	c.phase += delta
 	if c.phase >= 32 {
 		c.phase = 0
@@ -150,7 +150,7 @@ func (c *NoiseChannel) GetSample() int {
 	divisor := divisorTable[c.divisorCode]
 	frequency := 262144.0 / (divisor * math.Pow(2, float64(c.shift)))
 
-	phaseIncrement := frequency / float64(sampleRate)
+	phaseIncrement := frequency / float64(SampleRate)
 
 	// Actualizar fase y clock del LFSR
 	c.phase += phaseIncrement
@@ -0,0 +1,355 @@
+package apu
+
+// Standard MIDI File formato 1: una pista de tempo y una pista por canal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	midiDivision = 480    // Ticks por negra
+	midiTempo    = 500000 // Microsegundos por negra (120 BPM)
+	// Ticks por segundo con la división y el tempo anteriores
+	midiTicksPerSecond = midiDivision * 1000000 / midiTempo
+	// Rango del pitch bend en semitonos, configurado con RPN 0
+	midiBendRange = 2
+	// Duración de las notas de percusión del canal de ruido
+	midiDrumTicks = midiTicksPerSecond / 20
+
+	midiNoteOff    = 0x80
+	midiNoteOn     = 0x90
+	midiControl    = 0xB0
+	midiProgram    = 0xC0
+	midiPitchBend  = 0xE0
+	midiExpression = 11
+)
+
+// Canal MIDI de cada canal de la APU; el ruido va al canal de percusión
+var midiChannels = [4]byte{0, 1, 2, 9}
+
+var midiTrackNames = [4]string{"Square 1", "Square 2", "Wave", "Noise"}
+
+// Programas General MIDI: Lead 1 (square) y Lead 2 (sawtooth)
+var midiPrograms = [3]byte{80, 80, 81}
+
+// Registros NRx3 y NRx4 con el periodo de los canales 1-3
+var periodRegisters = [3][2]uint16{{0xFF13, 0xFF14}, {0xFF18, 0xFF19}, {0xFF1D, 0xFF1E}}
+
+type midiEvent struct {
+	tick uint32
+	data []byte
+}
+
+type midiVoice struct {
+	note       int // -1 si no suena ninguna nota
+	velocity   byte
+	initVolume int // Volumen (0-15) al disparar la nota
+	volume     int
+	bend       int // Valor de pitch bend actual (0-16383)
+	offTick    uint32
+}
+
+// MIDI transcribe las notas de los cuatro canales a partir de los disparos,
+// cambios de periodo y de volumen de la APU
+type MIDI struct {
+	startCycle uint64
+	tick       uint32
+	tracks     [4][]midiEvent
+	voices     [4]midiVoice
+}
+
+// StartMIDI comienza a transcribir las notas de la APU
+func (apu *APU) StartMIDI() {
+	m := &MIDI{startCycle: apu.cycles}
+	for i := range m.voices {
+		m.voices[i] = midiVoice{note: -1, bend: 8192}
+		ch := midiChannels[i]
+		if i < len(midiPrograms) {
+			m.add(i, midiProgram|ch, midiPrograms[i])
+			// RPN 0: rango del pitch bend
+			m.add(i, midiControl|ch, 101, 0)
+			m.add(i, midiControl|ch, 100, 0)
+			m.add(i, midiControl|ch, 6, midiBendRange)
+			m.add(i, midiControl|ch, 38, 0)
+		}
+	}
+	apu.midi = m
+}
+
+// StopMIDI termina la transcripción y la devuelve, o nil si no había ninguna
+func (apu *APU) StopMIDI() *MIDI {
+	m := apu.midi
+	if m == nil {
+		return nil
+	}
+	m.advance(apu.cycles)
+	for i := range m.voices {
+		m.noteOff(i)
+	}
+	apu.midi = nil
+	return m
+}
+
+// RecordingMIDI indica si hay una transcripción MIDI en curso
+func (apu *APU) RecordingMIDI() bool {
+	return apu.midi != nil
+}
+
+func (m *MIDI) advance(cycle uint64) {
+	m.tick = uint32((cycle - m.startCycle) * midiTicksPerSecond / cpuClock)
+}
+
+func (m *MIDI) add(track int, data ...byte) {
+	m.tracks[track] = append(m.tracks[track], midiEvent{tick: m.tick, data: data})
+}
+
+// onWrite detecta disparos y cambios de periodo antes de que el valor
+// llegue al registro
+func (m *MIDI) onWrite(apu *APU, addr uint16, value byte) {
+	m.advance(apu.cycles)
+	switch addr {
+	case 0xFF13, 0xFF18, 0xFF1D: // NRx3
+		i := periodChannel(addr)
+		period := uint16(value) | uint16(apu.bus.Read(periodRegisters[i][1])&0x07)<<8
+		m.setPeriod(i, period)
+	case 0xFF14, 0xFF19, 0xFF1E: // NRx4
+		i := periodChannel(addr)
+		period := uint16(apu.bus.Read(periodRegisters[i][0])) | uint16(value&0x07)<<8
+		if value&0x80 != 0 {
+			m.trigger(apu, i, period)
+		} else {
+			m.setPeriod(i, period)
+		}
+	case 0xFF1A: // NR30: apagar el DAC corta la nota
+		if value&0x80 == 0 {
+			m.noteOff(2)
+		}
+	case 0xFF23: // NR44
+		if value&0x80 != 0 {
+			m.triggerDrum(apu)
+		}
+	}
+}
+
+func periodChannel(addr uint16) int {
+	for i, regs := range periodRegisters {
+		if regs[0] == addr || regs[1] == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *MIDI) trigger(apu *APU, i int, period uint16) {
+	var volume int
+	switch i {
+	case 0:
+		volume = int(apu.bus.Read(0xFF12) >> 4)
+	case 1:
+		volume = int(apu.bus.Read(0xFF17) >> 4)
+	case 2:
+		if apu.bus.Read(0xFF1A)&0x80 == 0 {
+			return // DAC apagado
+		}
+		volume = waveVolume((apu.bus.Read(0xFF1C) >> 5) & 0x03)
+	}
+	m.noteOff(i)
+	if volume == 0 {
+		return
+	}
+	note, bend := periodToNote(i, period)
+	v := &m.voices[i]
+	v.velocity = byte(volume * 127 / 15)
+	v.initVolume = volume
+	v.volume = volume
+	m.add(i, midiControl|midiChannels[i], midiExpression, 127)
+	m.setBend(i, bend)
+	m.noteOn(i, note)
+}
+
+// setPeriod actualiza la nota que está sonando: dentro del mismo semitono
+// basta con el pitch bend, si no se reemplaza la nota
+func (m *MIDI) setPeriod(i int, period uint16) {
+	v := &m.voices[i]
+	if v.note < 0 {
+		return
+	}
+	note, bend := periodToNote(i, period)
+	m.setBend(i, bend)
+	if note != v.note {
+		m.noteOff(i)
+		m.noteOn(i, note)
+	}
+}
+
+func (m *MIDI) triggerDrum(apu *APU) {
+	volume := int(apu.bus.Read(0xFF21) >> 4)
+	m.noteOff(3)
+	if volume == 0 {
+		return
+	}
+	v := &m.voices[3]
+	v.velocity = byte(volume * 127 / 15)
+	v.offTick = m.tick + midiDrumTicks
+	m.noteOn(3, noiseToDrum(apu.bus.Read(0xFF22)))
+}
+
+func (m *MIDI) noteOn(i int, note int) {
+	v := &m.voices[i]
+	v.note = note
+	m.add(i, midiNoteOn|midiChannels[i], byte(note), v.velocity)
+}
+
+func (m *MIDI) noteOff(i int) {
+	v := &m.voices[i]
+	if v.note < 0 {
+		return
+	}
+	m.add(i, midiNoteOff|midiChannels[i], byte(v.note), 0)
+	v.note = -1
+}
+
+func (m *MIDI) setBend(i int, bend int) {
+	v := &m.voices[i]
+	if v.bend == bend {
+		return
+	}
+	v.bend = bend
+	m.add(i, midiPitchBend|midiChannels[i], byte(bend&0x7F), byte(bend>>7))
+}
+
+// poll sigue los cambios de volumen del envelope y el fin de las notas
+func (m *MIDI) poll(apu *APU) {
+	m.advance(apu.cycles)
+	channels := [3]*Channel{&apu.chan1.Channel, &apu.chan2.Channel, &apu.chan3.Channel}
+	for i, c := range channels {
+		v := &m.voices[i]
+		if v.note < 0 {
+			continue
+		}
+		volume := int(math.Round(c.volume * 15))
+		if i == 2 {
+			volume = waveVolume(byte(apu.chan3.volumeShift + 1))
+		}
+		if !c.enabled || volume == 0 {
+			m.noteOff(i)
+			continue
+		}
+		if volume != v.volume {
+			v.volume = volume
+			m.add(i, midiControl|midiChannels[i], midiExpression, byte(min(127, volume*127/v.initVolume)))
+		}
+	}
+	if m.voices[3].note >= 0 && m.tick >= m.voices[3].offTick {
+		m.noteOff(3)
+	}
+}
+
+// waveVolume convierte el código de volumen de NR32 a la escala 0-15
+func waveVolume(code byte) int {
+	return [4]int{0, 15, 8, 4}[code&0x03]
+}
+
+// periodToNote devuelve la nota MIDI más cercana al periodo del canal y el
+// pitch bend que corrige la diferencia
+func periodToNote(i int, period uint16) (note int, bend int) {
+	clock := 131072.0 // Canales square
+	if i == 2 {
+		clock = 65536.0 // El canal wave recorre 32 muestras por periodo
+	}
+	freq := clock / float64(2048-period)
+	exact := 69 + 12*math.Log2(freq/440)
+	note = int(math.Round(exact))
+	note = max(0, min(127, note))
+	bend = 8192 + int(math.Round((exact-float64(note))/midiBendRange*8192))
+	bend = max(0, min(16383, bend))
+	return note, bend
+}
+
+// noiseToDrum elige un instrumento de percusión General MIDI según la
+// frecuencia del ruido configurada en NR43
+func noiseToDrum(nr43 byte) int {
+	divisor := float64(nr43 & 0x07)
+	if divisor == 0 {
+		divisor = 0.5
+	}
+	freq := 262144 / (divisor * math.Pow(2, float64(nr43>>4)))
+	switch {
+	case freq >= 65536:
+		return 42 // Closed hi-hat
+	case freq >= 8192:
+		return 38 // Acoustic snare
+	default:
+		return 36 // Bass drum
+	}
+}
+
+// WriteTo escribe el Standard MIDI File completo
+func (m *MIDI) WriteTo(w io.Writer) (int64, error) {
+	var out bytes.Buffer
+	out.WriteString("MThd")
+	out.Write(binary.BigEndian.AppendUint32(nil, 6))
+	out.Write(binary.BigEndian.AppendUint16(nil, 1)) // Formato 1
+	out.Write(binary.BigEndian.AppendUint16(nil, uint16(len(m.tracks)+1)))
+	out.Write(binary.BigEndian.AppendUint16(nil, midiDivision))
+
+	tempo := []midiEvent{
+		{0, metaEvent(0x03, []byte("Liteboy"))},
+		{0, metaEvent(0x51, []byte{midiTempo >> 16, midiTempo >> 8 & 0xFF, midiTempo & 0xFF})},
+	}
+	writeMIDITrack(&out, tempo, m.tick)
+	for i, events := range m.tracks {
+		named := append([]midiEvent{{0, metaEvent(0x03, []byte(midiTrackNames[i]))}}, events...)
+		writeMIDITrack(&out, named, m.tick)
+	}
+	return out.WriteTo(w)
+}
+
+func metaEvent(kind byte, data []byte) []byte {
+	return append(appendVarLen([]byte{0xFF, kind}, uint32(len(data))), data...)
+}
+
+func writeMIDITrack(out *bytes.Buffer, events []midiEvent, endTick uint32) {
+	var track []byte
+	last := uint32(0)
+	for _, e := range events {
+		track = appendVarLen(track, e.tick-last)
+		track = append(track, e.data...)
+		last = e.tick
+	}
+	track = appendVarLen(track, endTick-last)
+	track = append(track, 0xFF, 0x2F, 0x00) // Fin de pista
+
+	out.WriteString("MTrk")
+	out.Write(binary.BigEndian.AppendUint32(nil, uint32(len(track))))
+	out.Write(track)
+}
+
+// appendVarLen codifica v como cantidad de longitud variable MIDI
+func appendVarLen(b []byte, v uint32) []byte {
+	var buf [5]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7F)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7F) | 0x80
+	}
+	return append(b, buf[i:]...)
+}
+
+// Save guarda la transcripción en path
+func (m *MIDI) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := m.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
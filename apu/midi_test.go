@@ -0,0 +1,88 @@
+package apu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func TestMIDITranscribesTriggeredNotes(t *testing.T) {
+	b := bus.NewBus(nil)
+	apu := NewAPU(b, nil)
+	apu.StartMIDI()
+
+	// Square 1 a 440 Hz: periodo 2048 - 131072/440 ≈ 1750 (0x6D6)
+	b.Client = bus.ClientCPU
+	b.Write(0xFF12, 0xF0)
+	b.Write(0xFF13, 0xD6)
+	b.Write(0xFF14, 0x86)
+	// Ruido de baja frecuencia
+	b.Write(0xFF21, 0xF0)
+	b.Write(0xFF22, 0x77)
+	b.Write(0xFF23, 0x80)
+	for range 1000 {
+		apu.Step()
+	}
+	midi := apu.StopMIDI()
+	if apu.RecordingMIDI() {
+		t.Fatal("la transcripción no terminó")
+	}
+
+	var out bytes.Buffer
+	if _, err := midi.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	file := out.Bytes()
+	if string(file[:4]) != "MThd" || file[11] != 5 {
+		t.Fatalf("cabecera MIDI inválida: % X", file[:14])
+	}
+	if bytes.Count(file, []byte("MTrk")) != 5 {
+		t.Fatal("se esperaban 5 pistas")
+	}
+	if !bytes.Contains(file, []byte{midiNoteOn, 69, 127}) {
+		t.Fatal("falta el note on del A4 en el canal 1")
+	}
+	if !bytes.Contains(file, []byte{midiNoteOn | 9, 36, 127}) {
+		t.Fatal("falta la nota de percusión del canal de ruido")
+	}
+	if !bytes.Contains(file, []byte{midiNoteOff, 69, 0}) {
+		t.Fatal("las notas deben terminar al detener la transcripción")
+	}
+}
+
+func TestPeriodToNote(t *testing.T) {
+	cases := []struct {
+		channel int
+		period  uint16
+		note    int
+	}{
+		{0, 1750, 69}, // 131072/298 = 439.8 Hz (A4)
+		{0, 1798, 72}, // 131072/250 = 524.3 Hz (C5)
+		{2, 1923, 72}, // 65536/125 = 524.3 Hz (C5)
+	}
+	for _, c := range cases {
+		note, bend := periodToNote(c.channel, c.period)
+		if note != c.note {
+			t.Errorf("canal %d periodo %d: nota %d, se esperaba %d", c.channel, c.period, note, c.note)
+		}
+		if bend < 8192-2048 || bend > 8192+2048 {
+			t.Errorf("canal %d periodo %d: pitch bend %d fuera de medio semitono", c.channel, c.period, bend)
+		}
+	}
+}
+
+func TestAppendVarLen(t *testing.T) {
+	cases := map[uint32][]byte{
+		0:        {0x00},
+		0x7F:     {0x7F},
+		0x80:     {0x81, 0x00},
+		0x3FFF:   {0xFF, 0x7F},
+		0x200000: {0x81, 0x80, 0x80, 0x00},
+	}
+	for v, want := range cases {
+		if got := appendVarLen(nil, v); !bytes.Equal(got, want) {
+			t.Errorf("appendVarLen(%X) = % X, se esperaba % X", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package apu
+
+type Mixer struct {
+	ch1         *SquareChannel
+	ch2         *SquareChannel
+	ch3         *WaveChannel
+	ch4         *NoiseChannel
+	leftVolume  float64
+	rightVolume float64
+	// Última muestra generada por cada canal antes de la mezcla
+	channels [4]int16
+}
+
+// Sample genera una muestra estéreo mezclando los cuatro canales
+func (m *Mixer) Sample() (left, right int16) {
+	// Obtener las muestras individuales
+	s1 := int32(m.ch1.GetSample())
+	s2 := int32(m.ch2.GetSample())
+	s3 := int32(m.ch3.GetSample())
+	s4 := int32(m.ch4.GetSample())
+	m.channels = [4]int16{int16(s1), int16(s2), int16(s3), int16(s4)}
+
+	// Mezcla simple promedio
+	mixed := (s1 + s2 + s3 + s4) / 4
+	// Clipping: limitar a int16
+	if mixed > 32767 {
+		mixed = 32767
+	} else if mixed < -32768 {
+		mixed = -32768
+	}
+
+	sample := int(mixed)
+	left = int16((sample * int(m.leftVolume*10000)) / 10000)
+	right = int16((sample * int(m.rightVolume*10000)) / 10000)
+	return left, right
+}
@@ -0,0 +1,78 @@
+package apu
+
+import "sync"
+
+// AudioSink recibe las muestras estéreo de 16 bits que genera la APU a
+// SampleRate muestras por segundo de tiempo emulado.
+type AudioSink interface {
+	WriteSample(left, right int16)
+}
+
+// ChannelSink es un AudioSink que además recibe la salida de cada canal
+// (square 1, square 2, wave y noise) antes de la mezcla. La APU llama a
+// WriteChannelSamples justo antes de WriteSample para el mismo instante.
+type ChannelSink interface {
+	AudioSink
+	WriteChannelSamples(channels [4]int16)
+}
+
+// NullSink descarta todas las muestras, útil para tests y herramientas sin audio
+type NullSink struct{}
+
+func (NullSink) WriteSample(left, right int16) {}
+
+// BufferSink acumula las muestras en memoria como PCM estéreo intercalado
+// (izquierda, derecha, izquierda, ...)
+type BufferSink struct {
+	mu      sync.Mutex
+	samples []int16
+}
+
+func NewBufferSink() *BufferSink {
+	return &BufferSink{}
+}
+
+func (s *BufferSink) WriteSample(left, right int16) {
+	s.mu.Lock()
+	s.samples = append(s.samples, left, right)
+	s.mu.Unlock()
+}
+
+// Samples devuelve una copia de las muestras acumuladas
+func (s *BufferSink) Samples() []int16 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]int16, len(s.samples))
+	copy(out, s.samples)
+	return out
+}
+
+// Drain devuelve las muestras acumuladas y vacía el buffer
+func (s *BufferSink) Drain() []int16 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.samples
+	s.samples = nil
+	return out
+}
+
+type multiSink []AudioSink
+
+// MultiSink reenvía cada muestra a todos los sinks indicados
+func MultiSink(sinks ...AudioSink) AudioSink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) WriteSample(left, right int16) {
+	for _, sink := range m {
+		sink.WriteSample(left, right)
+	}
+}
+
+func (m multiSink) WriteChannelSamples(channels [4]int16) {
+	for _, sink := range m {
+		if cs, ok := sink.(ChannelSink); ok {
+			cs.WriteChannelSamples(channels)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package apu
+
+// Formato VGM 1.61 con el chip Game Boy DMG: https://vgmrips.net/wiki/VGM_Specification
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+const (
+	vgmHeaderSize = 0x100
+	vgmVersion    = 0x161
+	// Los tiempos del VGM se expresan en muestras de 44100 Hz
+	vgmSampleRate = 44100
+
+	vgmCmdDMGWrite = 0xB3 // aa dd: escribe dd en el registro 0xFF10+aa
+	vgmCmdWait     = 0x61 // nn nn: espera n muestras
+	vgmCmdWait735  = 0x62 // espera 1/60 de segundo
+	vgmCmdEnd      = 0x66
+)
+
+// Orden en que se escriben los registros al comenzar una grabación: primero
+// se enciende la APU (NR52), luego el volumen, la wave RAM y los canales
+var vgmInitialRegisters = []uint16{
+	0xFF26, 0xFF24, 0xFF25,
+	0xFF30, 0xFF31, 0xFF32, 0xFF33, 0xFF34, 0xFF35, 0xFF36, 0xFF37,
+	0xFF38, 0xFF39, 0xFF3A, 0xFF3B, 0xFF3C, 0xFF3D, 0xFF3E, 0xFF3F,
+	0xFF10, 0xFF11, 0xFF12, 0xFF13, 0xFF14,
+	0xFF16, 0xFF17, 0xFF18, 0xFF19,
+	0xFF1A, 0xFF1B, 0xFF1C, 0xFF1D, 0xFF1E,
+	0xFF20, 0xFF21, 0xFF22, 0xFF23,
+}
+
+// VGM contiene las escrituras en los registros de sonido con su instante
+// en t-ciclos emulados
+type VGM struct {
+	data       bytes.Buffer
+	startCycle uint64
+	lastSample uint64
+}
+
+// StartVGM comienza a registrar las escrituras de la CPU en 0xFF10-0xFF3F.
+// El estado actual de los registros se escribe al inicio de la grabación.
+func (apu *APU) StartVGM() {
+	vgm := &VGM{startCycle: apu.cycles}
+	for _, addr := range vgmInitialRegisters {
+		value := apu.bus.Read(addr)
+		switch addr {
+		case 0xFF26:
+			value &= 0x80 // Los bits de estado son de solo lectura
+		case 0xFF14, 0xFF19, 0xFF1E, 0xFF23:
+			// Solo se vuelven a disparar los canales que están sonando
+			if !apu.channelEnabled(addr) {
+				value &^= 0x80
+			}
+		}
+		vgm.write(addr, value)
+	}
+	apu.vgm = vgm
+}
+
+// StopVGM termina la grabación y la devuelve, o nil si no había ninguna
+func (apu *APU) StopVGM() *VGM {
+	vgm := apu.vgm
+	if vgm == nil {
+		return nil
+	}
+	vgm.wait(apu.cycles)
+	apu.vgm = nil
+	return vgm
+}
+
+// RecordingVGM indica si hay una grabación VGM en curso
+func (apu *APU) RecordingVGM() bool {
+	return apu.vgm != nil
+}
+
+func (apu *APU) channelEnabled(nrx4 uint16) bool {
+	switch nrx4 {
+	case 0xFF14:
+		return apu.chan1.enabled
+	case 0xFF19:
+		return apu.chan2.enabled
+	case 0xFF1E:
+		return apu.chan3.enabled
+	default:
+		return apu.chan4.enabled
+	}
+}
+
+func (v *VGM) write(addr uint16, value byte) {
+	v.data.Write([]byte{vgmCmdDMGWrite, byte(addr - 0xFF10), value})
+}
+
+// wait agrega las esperas necesarias hasta el t-ciclo indicado
+func (v *VGM) wait(cycle uint64) {
+	sample := (cycle - v.startCycle) * vgmSampleRate / cpuClock
+	for sample > v.lastSample {
+		n := min(sample-v.lastSample, 0xFFFF)
+		switch {
+		case n == 735:
+			v.data.WriteByte(vgmCmdWait735)
+		case n <= 16:
+			v.data.WriteByte(0x70 + byte(n-1)) // 0x7n: espera n+1 muestras
+		default:
+			v.data.Write([]byte{vgmCmdWait, byte(n), byte(n >> 8)})
+		}
+		v.lastSample += n
+	}
+}
+
+// Samples devuelve la duración de la grabación en muestras de 44100 Hz
+func (v *VGM) Samples() uint64 {
+	return v.lastSample
+}
+
+// WriteTo escribe el archivo VGM completo
+func (v *VGM) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, vgmHeaderSize)
+	copy(header, "Vgm ")
+	size := vgmHeaderSize + v.data.Len() + 1
+	binary.LittleEndian.PutUint32(header[0x04:], uint32(size-0x04))    // EOF offset
+	binary.LittleEndian.PutUint32(header[0x08:], vgmVersion)           // Versión
+	binary.LittleEndian.PutUint32(header[0x18:], uint32(v.lastSample)) // Total de muestras
+	binary.LittleEndian.PutUint32(header[0x24:], 60)                   // Frecuencia de refresco
+	binary.LittleEndian.PutUint32(header[0x34:], vgmHeaderSize-0x34)   // Offset de los datos
+	binary.LittleEndian.PutUint32(header[0x80:], cpuClock)             // Reloj del Game Boy DMG
+
+	var n int64
+	for _, chunk := range [][]byte{header, v.data.Bytes(), {vgmCmdEnd}} {
+		written, err := w.Write(chunk)
+		n += int64(written)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Save guarda la grabación en path
+func (v *VGM) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := v.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
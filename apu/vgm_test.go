@@ -0,0 +1,60 @@
+package apu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func TestVGMRecordsCPUSoundWrites(t *testing.T) {
+	b := bus.NewBus(nil)
+	apu := NewAPU(b, nil)
+	apu.StartVGM()
+	if !apu.RecordingVGM() {
+		t.Fatal("la grabación no comenzó")
+	}
+
+	// La APU escribe NR52 en cada paso y no debe quedar registrado
+	for range 70224 / 4 {
+		apu.Step()
+	}
+	b.Client = bus.ClientCPU
+	b.Write(0xFF12, 0xF0)
+	b.Write(0xFF40, 0x91) // Fuera del rango de sonido
+	for range 100 {
+		apu.Step()
+	}
+	vgm := apu.StopVGM()
+	if apu.RecordingVGM() || apu.StopVGM() != nil {
+		t.Fatal("la grabación no terminó")
+	}
+
+	var out bytes.Buffer
+	if _, err := vgm.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	file := out.Bytes()
+	if string(file[:4]) != "Vgm " || binary.LittleEndian.Uint32(file[0x04:]) != uint32(len(file)-4) {
+		t.Fatal("cabecera VGM inválida")
+	}
+	if binary.LittleEndian.Uint32(file[0x80:]) != cpuClock {
+		t.Fatal("falta el reloj del Game Boy en la cabecera")
+	}
+
+	data := file[vgmHeaderSize:]
+	initial := data[:3*len(vgmInitialRegisters)]
+	if initial[0] != vgmCmdDMGWrite || initial[1] != 0x16 || initial[2] != 0x80 {
+		t.Fatalf("la grabación debe comenzar encendiendo la APU: % X", initial[:3])
+	}
+	rest := data[len(initial):]
+	// 70224 t-ciclos = 738 muestras antes de la escritura y 400 más = 4 muestras
+	want := []byte{vgmCmdWait, 0xE2, 0x02, vgmCmdDMGWrite, 0x02, 0xF0, 0x70 + 3, vgmCmdEnd}
+	if !bytes.Equal(rest, want) {
+		t.Fatalf("comandos = % X, se esperaba % X", rest, want)
+	}
+	if vgm.Samples() != 742 {
+		t.Fatalf("duración = %d muestras", vgm.Samples())
+	}
+}
@@ -0,0 +1,150 @@
+package apu
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tamaño de la cabecera RIFF/WAVE con un único bloque "fmt " PCM
+const wavHeaderSize = 44
+
+// WAVWriter escribe audio PCM de 16 bits en un archivo WAV. Los tamaños de
+// la cabecera se completan al cerrar el archivo.
+type WAVWriter struct {
+	file     *os.File
+	w        *bufio.Writer
+	channels int
+	dataSize uint32
+}
+
+// CreateWAV crea el archivo en path con el número de canales indicado
+// (1 = mono, 2 = estéreo) a SampleRate Hz
+func CreateWAV(path string, channels int) (*WAVWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &WAVWriter{file: file, w: bufio.NewWriter(file), channels: channels}
+	if err := w.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *WAVWriter) writeHeader() error {
+	blockAlign := uint16(w.channels * 2)
+	header := make([]byte, 0, wavHeaderSize)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, 36+w.dataSize)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16) // Tamaño del bloque fmt
+	header = binary.LittleEndian.AppendUint16(header, 1)  // PCM
+	header = binary.LittleEndian.AppendUint16(header, uint16(w.channels))
+	header = binary.LittleEndian.AppendUint32(header, SampleRate)
+	header = binary.LittleEndian.AppendUint32(header, SampleRate*uint32(blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, blockAlign)
+	header = binary.LittleEndian.AppendUint16(header, 16) // Bits por muestra
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, w.dataSize)
+	_, err := w.w.Write(header)
+	return err
+}
+
+// Write agrega muestras intercaladas por canal
+func (w *WAVWriter) Write(samples ...int16) error {
+	var buf [2]byte
+	for _, s := range samples {
+		binary.LittleEndian.PutUint16(buf[:], uint16(s))
+		if _, err := w.w.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+	w.dataSize += uint32(len(samples) * 2)
+	return nil
+}
+
+// Close reescribe la cabecera con los tamaños finales y cierra el archivo
+func (w *WAVWriter) Close() error {
+	err := w.w.Flush()
+	if err == nil {
+		_, err = w.file.Seek(0, 0)
+	}
+	if err == nil {
+		w.w.Reset(w.file)
+		err = w.writeHeader()
+	}
+	if err == nil {
+		err = w.w.Flush()
+	}
+	return errors.Join(err, w.file.Close())
+}
+
+// WAVRecorder es un ChannelSink que guarda la mezcla estéreo en un WAV y,
+// opcionalmente, un WAV mono por canal (chan1-chan4)
+type WAVRecorder struct {
+	Path  string
+	mix   *WAVWriter
+	stems [4]*WAVWriter
+	err   error
+}
+
+// NewWAVRecorder crea la grabación en path; con stems también crea los
+// archivos devueltos por StemPath para cada canal
+func NewWAVRecorder(path string, stems bool) (*WAVRecorder, error) {
+	mix, err := CreateWAV(path, 2)
+	if err != nil {
+		return nil, err
+	}
+	r := &WAVRecorder{Path: path, mix: mix}
+	if stems {
+		for i := range r.stems {
+			r.stems[i], err = CreateWAV(StemPath(path, i+1), 1)
+			if err != nil {
+				r.Close()
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// StemPath devuelve la ruta del WAV del canal (1-4) para una grabación,
+// por ejemplo "musica.wav" -> "musica_chan1.wav"
+func StemPath(path string, channel int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_chan%d%s", strings.TrimSuffix(path, ext), channel, ext)
+}
+
+func (r *WAVRecorder) WriteSample(left, right int16) {
+	if r.err == nil {
+		r.err = r.mix.Write(left, right)
+	}
+}
+
+func (r *WAVRecorder) WriteChannelSamples(channels [4]int16) {
+	for i, stem := range r.stems {
+		if stem != nil && r.err == nil {
+			r.err = stem.Write(channels[i])
+		}
+	}
+}
+
+// Close cierra todos los archivos y devuelve el primer error de escritura
+func (r *WAVRecorder) Close() error {
+	errs := []error{r.err}
+	if r.mix != nil {
+		errs = append(errs, r.mix.Close())
+	}
+	for _, stem := range r.stems {
+		if stem != nil {
+			errs = append(errs, stem.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
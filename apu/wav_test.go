@@ -0,0 +1,61 @@
+package apu
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func TestWAVRecorderWritesMixAndStems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "musica.wav")
+	recorder, err := NewWAVRecorder(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apu := NewAPU(bus.NewBus(nil), recorder)
+	for range 70224 / 4 {
+		apu.Step()
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := SampleRate * 70224 / cpuClock
+	checkWAV(t, path, 2, frames)
+	for ch := 1; ch <= 4; ch++ {
+		checkWAV(t, StemPath(path, ch), 1, frames)
+	}
+}
+
+func checkWAV(t *testing.T, path string, channels, minFrames int) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[0:4]) != "RIFF" || string(data[8:16]) != "WAVEfmt " {
+		t.Fatalf("%s: cabecera inválida", path)
+	}
+	if got := int(binary.LittleEndian.Uint16(data[22:])); got != channels {
+		t.Fatalf("%s: canales = %d, se esperaban %d", path, got, channels)
+	}
+	dataSize := int(binary.LittleEndian.Uint32(data[40:]))
+	if dataSize != len(data)-wavHeaderSize {
+		t.Fatalf("%s: tamaño de datos = %d, el archivo tiene %d", path, dataSize, len(data)-wavHeaderSize)
+	}
+	if int(binary.LittleEndian.Uint32(data[4:])) != len(data)-8 {
+		t.Fatalf("%s: tamaño RIFF incorrecto", path)
+	}
+	if dataSize/(2*channels) < minFrames {
+		t.Fatalf("%s: %d muestras, se esperaban al menos %d", path, dataSize/(2*channels), minFrames)
+	}
+}
+
+func TestStemPath(t *testing.T) {
+	if got := StemPath("out/tetris.wav", 3); got != "out/tetris_chan3.wav" {
+		t.Fatalf("StemPath = %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+//go:build !tui
+
+package main
+
+import (
+	"encoding/binary"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const (
+	// Bytes por muestra estéreo de 16 bits
+	audioFrameSize = 4
+	// Máximo de audio pendiente antes de descartar muestras viejas (~100 ms)
+	maxAudioBuffered = apu.SampleRate / 10 * audioFrameSize
+	// Silencio entregado al reproductor cuando no hay muestras (~14 ms)
+	audioSilenceChunk = 620
+)
+
+// Ebitengine solo permite un contexto de audio por proceso
+var audioContext *audio.Context
+
+// ebitenSink reproduce las muestras de la APU a través de Ebitengine
+type ebitenSink struct {
+	mu     sync.Mutex
+	buffer []byte
+	player *audio.Player
+}
+
+func newEbitenSink() (*ebitenSink, error) {
+	if audioContext == nil {
+		audioContext = audio.NewContext(apu.SampleRate)
+	}
+	sink := &ebitenSink{}
+	player, err := audioContext.NewPlayer(sink)
+	if err != nil {
+		return nil, err
+	}
+	player.SetBufferSize(50 * time.Millisecond)
+	player.Play()
+	sink.player = player
+	return sink, nil
+}
+
+func (s *ebitenSink) WriteSample(left, right int16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffer = binary.LittleEndian.AppendUint16(s.buffer, uint16(left))
+	s.buffer = binary.LittleEndian.AppendUint16(s.buffer, uint16(right))
+	// En avance rápido se generan más muestras de las que se reproducen
+	if len(s.buffer) > maxAudioBuffered {
+		s.buffer = s.buffer[len(s.buffer)-maxAudioBuffered:]
+	}
+}
+
+// Read entrega al reproductor las muestras pendientes en PCM little endian
+func (s *ebitenSink) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(p) - len(p)%audioFrameSize
+	if len(s.buffer) == 0 {
+		// Sin muestras pendientes: entregamos un poco de silencio
+		n = min(n, audioSilenceChunk)
+		clear(p[:n])
+		return n, nil
+	}
+	n = min(n, len(s.buffer))
+	copy(p, s.buffer[:n])
+	s.buffer = s.buffer[n:]
+	return n, nil
+}
+
+func (s *ebitenSink) Close() error {
+	return s.player.Close()
+}
+
+// wavCapture conecta y desconecta una grabación WAV de la salida de la APU
+// sin interrumpir la reproducción
+type wavCapture struct {
+	playback apu.AudioSink
+	recorder *apu.WAVRecorder
+	stems    bool
+}
+
+// sink devuelve el destino de audio que debe usar la APU
+func (c *wavCapture) sink() apu.AudioSink {
+	if c.recorder == nil {
+		return c.playback
+	}
+	return apu.MultiSink(c.playback, c.recorder)
+}
+
+func (c *wavCapture) start(a *apu.APU, path string) {
+	recorder, err := apu.NewWAVRecorder(path, c.stems)
+	if err != nil {
+		log.Println("error al iniciar la grabación de audio:", err)
+		return
+	}
+	c.recorder = recorder
+	a.SetSink(c.sink())
+	log.Println("Grabando audio en", path)
+}
+
+func (c *wavCapture) stop(a *apu.APU) {
+	if c.recorder == nil {
+		return
+	}
+	recorder := c.recorder
+	c.recorder = nil
+	a.SetSink(c.sink())
+	if err := recorder.Close(); err != nil {
+		log.Println("error al guardar la grabación de audio:", err)
+	} else {
+		log.Println("Audio guardado en", recorder.Path)
+	}
+}
+
+func (c *wavCapture) toggle(a *apu.APU, path string) {
+	if c.recorder == nil {
+		c.start(a, path)
+	} else {
+		c.stop(a)
+	}
+}
@@ -5,11 +5,7 @@ import (
 	"testing"
 
 	"github.com/deybismelendez/liteboy/apu"
-	"github.com/deybismelendez/liteboy/bus"
 	"github.com/deybismelendez/liteboy/cartridge"
-	"github.com/deybismelendez/liteboy/cpu"
-	"github.com/deybismelendez/liteboy/ppu"
-	"github.com/deybismelendez/liteboy/timer"
 )
 
 var cpu_instrs = map[string]string{
@@ -43,8 +39,8 @@ var mem_timing_2 = map[string]string{
 func TestBlargg_cpu_instrs(t *testing.T) {
 	for name, path := range cpu_instrs {
 		t.Run(name, func(t *testing.T) {
-			if ok := runTestROM(path); !ok {
-				t.Errorf("Test %s failed", name)
+			if output, ok := runTestROM(path); !ok {
+				t.Errorf("Test %s failed: %q", name, output)
 			}
 		})
 	}
@@ -53,8 +49,8 @@ func TestBlargg_cpu_instrs(t *testing.T) {
 func TestBlargg_instr_timing(t *testing.T) {
 	for name, path := range instr_timing {
 		t.Run(name, func(t *testing.T) {
-			if ok := runTestROM(path); !ok {
-				t.Errorf("Test %s failed", name)
+			if output, ok := runTestROM(path); !ok {
+				t.Errorf("Test %s failed: %q", name, output)
 			}
 		})
 	}
@@ -62,8 +58,8 @@ func TestBlargg_instr_timing(t *testing.T) {
 func TestBlargg_mem_timing(t *testing.T) {
 	for name, path := range mem_timing {
 		t.Run(name, func(t *testing.T) {
-			if ok := runTestROM(path); !ok {
-				t.Errorf("Test %s failed", name)
+			if output, ok := runTestROM(path); !ok {
+				t.Errorf("Test %s failed: %q", name, output)
 			}
 		})
 	}
@@ -71,72 +67,63 @@ func TestBlargg_mem_timing(t *testing.T) {
 func TestBlargg_mem_timing_2(t *testing.T) {
 	for name, path := range mem_timing_2 {
 		t.Run(name, func(t *testing.T) {
-			if ok := runTestROM(path); !ok {
-				t.Errorf("Test %s failed", name)
+			if output, ok := runTestROM(path); !ok {
+				t.Errorf("Test %s failed: %q", name, output)
 			}
 		})
 	}
 }
 
-func runTestROM(path string) bool {
+// Emula el ROM hasta que informa "Passed" o "Failed" y devuelve el texto del
+// resultado. Se lee primero el puerto serie; los tests que no lo usan, como
+// mem_timing-2 y oam_bug, dejan el resultado en 0xA000 o solo en la pantalla.
+func runTestROM(path string) (string, bool) {
 	cart := cartridge.NewCartridge(path)
-	gameBus := bus.NewBus(cart)
-	gamePPU := ppu.NewPPU(gameBus)
-	gameTimer := timer.NewTimer(gameBus)
-	gameAPU := apu.NewAPU(gameBus)
-	gameCPU := cpu.NewCPU(gameBus, gameTimer, gamePPU, gameAPU)
+	m := newMachine(cart, apu.NullSink{})
+	var serial strings.Builder
+	m.bus.OnSerialTransfer = func(value byte) {
+		serial.WriteByte(value)
+	}
 
-	for range 20 {
+	text := ""
+	for range 40 {
 		for range 400_000 {
-			gameCPU.Step()
-		}
-		// Inspecciona el texto en pantalla (desde VRAM)
-		text := extractScreenText(gameBus)
-		if strings.Contains(text, "Passed") {
-			return true
+			m.cpu.Step()
 		}
-	}
-	return false // Timeout
-}
-
-// Lee los primeros caracteres del tile map en VRAM para detectar texto
-func extractScreenText(b *bus.Bus) string {
-	vram := b.VRAM
-	base := 0x1800 // VRAM offset para $9800
-	var out strings.Builder
-
-	for i := 0; i < 32*32; i++ { // Lee ~4 filas de 32 tiles
-		if i+base >= len(vram) {
-			break
+		if text = serial.String(); text == "" {
+			if status, message, ok := blarggMemoryResult(m.bus); ok {
+				return message, status == testPass
+			}
+			text = extractScreenText(m.bus)
 		}
-		tile := vram[base+i]
-		// Blargg imprime caracteres ASCII (espacio=0x20)
-		if tile >= 0x20 && tile <= 0x7F {
-			out.WriteByte(tile)
-		} else {
-			out.WriteByte('.') // Placeholder
+		if status, ok := textResult(text); ok {
+			return text, status == testPass
 		}
 	}
-	return out.String()
+	return text, false // Timeout
 }
 
-/*var oam_bug = map[string]string{
+var oam_bug = map[string]string{
 	"1-lcd_sync":        "roms/blargg/oam_bug/rom_singles/1-lcd_sync.gb",
 	"2-causes":          "roms/blargg/oam_bug/rom_singles/2-causes.gb",
 	"3-non_causes":      "roms/blargg/oam_bug/rom_singles/3-non_causes.gb",
 	"4-scanline_timing": "roms/blargg/oam_bug/rom_singles/4-scanline_timing.gb",
 	"5-timing_bug":      "roms/blargg/oam_bug/rom_singles/5-timing_bug.gb",
 	"6-timing_no_bug":   "roms/blargg/oam_bug/rom_singles/6-timing_no_bug.gb",
-	"7-timing_effect":   "roms/blargg/oam_bug/rom_singles/7-timing_effect.gb",
-	"8-instr_effect":    "roms/blargg/oam_bug/rom_singles/8-instr_effect.gb",
-}*/
+	// 7-timing_effect imprime una tabla por cada tiempo que corrompe la OAM y
+	// el texto no cabe en los 8KB del búfer en 0xA000: pisa su propio código
+	// en 0xC000 y el ROM se reinicia. Se comprueba con el ROM combinado, que
+	// verifica el mismo test con un CRC.
+	"8-instr_effect": "roms/blargg/oam_bug/rom_singles/8-instr_effect.gb",
+	"oam_bug":        "roms/blargg/oam_bug/oam_bug.gb",
+}
 
-/*func TestBlargg_oam_bug(t *testing.T) {
+func TestBlargg_oam_bug(t *testing.T) {
 	for name, path := range oam_bug {
 		t.Run(name, func(t *testing.T) {
-			if ok := runTestROM(path); !ok {
-				t.Errorf("Test %s failed", name)
+			if output, ok := runTestROM(path); !ok {
+				t.Errorf("Test %s failed: %q", name, output)
 			}
 		})
 	}
-}*/
+}
@@ -7,9 +7,15 @@ import (
 )
 
 const (
+	SBRegister   = 0xFF01
+	SCRegister   = 0xFF02
 	DIVRegister  = 0xFF04
 	TIMARegister = 0xFF05
 	TACRegister  = 0xFF07
+	IFRegister   = 0xFF0F
+	LCDCRegister = 0xFF40
+	STATRegister = 0xFF41
+	LYRegister   = 0xFF44
 
 	ClientCPU     = 0
 	ClientPPU     = 1
@@ -47,9 +53,45 @@ type Bus struct {
 	dmaDelay         byte    // ciclos de retardo inicial (2)
 	pendingDMASource *uint16 // nuevo origen DMA si hay reinicio
 	Client           byte
+	// Valor que la CPU escribió en STAT, pendiente hasta el final del ciclo
+	// de la escritura (ver stat.go)
+	pendingSTAT *byte
+	// Informa en el log los accesos de la CPU a VRAM/OAM bloqueados por el
+	// modo del PPU, para encontrar juegos que no respetan los timings
+	ReportBlockedAccess bool
+	BlockedAccesses     int // Accesos bloqueados por el modo del PPU
+	// Momentos de la línea en que el bloqueo del PPU no sigue al modo de
+	// STAT (ver isLockedByPPU)
+	LYChanging  bool // LY ya pasó a la línea siguiente y STAT sigue en modo 0
+	OAMScanDone bool // Últimos dots del modo 2, con la OAM ya leída
+	// Callback para el bug de corrupción de la OAM: la CPU leyó o escribió
+	// en 0xFE00-0xFEFF
+	OnOAMAccess func(addr uint16, write bool)
+	// Callback opcional para las escrituras de la CPU en los registros de
+	// sonido (0xFF10-0xFF3F), usado para grabar la música del juego
+	OnSoundWrite func(addr uint16, value byte)
+	// Callback opcional para los bytes que la CPU envía por el puerto serie:
+	// se llama con el valor de SB cuando escribe en SC una transferencia con
+	// reloj interno (bits 7 y 0). Los test ROMs imprimen ahí sus resultados
+	OnSerialTransfer func(value byte)
+	serialCycles     int // T-ciclos que le quedan a la transferencia serie
 }
 
 func (b *Bus) Read(addr uint16) byte {
+	if b.OnOAMAccess != nil && b.Client == ClientCPU && addr >= 0xFE00 && addr <= 0xFEFF {
+		b.OnOAMAccess(addr, false)
+	}
+	return b.ReadNoOAMBug(addr)
+}
+
+// ReadNoOAMBug lee como Read pero sin avisar a OnOAMAccess. La CPU la usa
+// en las lecturas que coinciden con un incremento (POP, LD A,[HL+] y
+// LD A,[HL-]), en las que ella misma aplica la corrupción de ese ciclo
+func (b *Bus) ReadNoOAMBug(addr uint16) byte {
+	if b.isLockedByPPU(addr, false) {
+		b.reportBlocked("lectura", addr)
+		return 0xFF
+	}
 	if !b.isAccessible(addr) {
 		log.Printf("Acceso denegado en lectura para el cliente %d en %04X\n", b.Client, addr)
 		return 0xFF
@@ -82,6 +124,10 @@ func (b *Bus) Read(addr uint16) byte {
 		if addr == 0xFF0F {
 			return b.IO[addr-0xFF00] | 0xE0
 		}
+		// El bit 7 de STAT no se usa y siempre se lee con 1
+		if addr == STATRegister {
+			return b.IO[addr-0xFF00] | 0x80
+		}
 		return b.IO[addr-0xFF00]
 
 	case addr >= 0xFF80 && addr < 0xFFFF:
@@ -97,6 +143,13 @@ func (b *Bus) Read(addr uint16) byte {
 }
 
 func (b *Bus) Write(addr uint16, value byte) {
+	if b.OnOAMAccess != nil && b.Client == ClientCPU && addr >= 0xFE00 && addr <= 0xFEFF {
+		b.OnOAMAccess(addr, true)
+	}
+	if b.isLockedByPPU(addr, true) {
+		b.reportBlocked("escritura", addr)
+		return
+	}
 	if !b.isAccessible(addr) {
 		log.Printf("Acceso denegado en escritura para el cliente %d en %04X\n", b.Client, addr)
 		return
@@ -123,6 +176,12 @@ func (b *Bus) Write(addr uint16, value byte) {
 		log.Printf("Intento de escritura en zona no usable en %04X: %02X por cliente %d\n", addr, value, b.Client)
 
 	case addr >= 0xFF00 && addr < 0xFF80:
+		if b.OnSoundWrite != nil && b.Client == ClientCPU && addr >= 0xFF10 && addr < 0xFF40 {
+			b.OnSoundWrite(addr, value)
+		}
+		if b.Client == ClientCPU && addr == SCRegister && value&0x81 == 0x81 {
+			b.startSerialTransfer()
+		}
 		/*if addr == TIMARegister && b.TimerReloading {
 			b.TimerReloading = false
 			b.IO[addr-0xFF00] = value
@@ -141,6 +200,11 @@ func (b *Bus) Write(addr uint16, value byte) {
 			b.ResetDIV = true
 			return
 		}
+		// Los bits 0-2 de STAT (modo y coincidencia LY=LYC) son de solo lectura
+		if b.Client == ClientCPU && addr == STATRegister {
+			b.writeSTAT(value)
+			return
+		}
 		// Activa el DMA
 		if addr == 0xFF46 {
 			b.IO[addr-0xFF00] = value
@@ -197,3 +261,39 @@ func (b *Bus) isAccessible(addr uint16) bool {
 		return false
 	}
 }
+
+// Cantidad de accesos bloqueados que se informan antes de dejar de hacerlo
+const maxBlockedReports = 100
+
+// isLockedByPPU indica si el PPU tiene ocupada la memoria para la CPU: la OAM
+// durante los modos 2 y 3 y la VRAM durante el modo 3. La lectura de la OAM
+// se bloquea desde que cambia LY y la de la VRAM en los últimos dots del
+// modo 2, cuando la OAM ya se puede escribir. Con el LCD apagado no hay
+// bloqueo.
+func (b *Bus) isLockedByPPU(addr uint16, write bool) bool {
+	if b.Client != ClientCPU || b.IO[LCDCRegister-0xFF00]&0x80 == 0 {
+		return false
+	}
+	mode := b.IO[STATRegister-0xFF00] & 0x03
+	switch {
+	case addr >= 0xFE00 && addr < 0xFEA0:
+		if write {
+			return mode == 3 || mode == 2 && !b.OAMScanDone
+		}
+		return mode == 2 || mode == 3 || b.LYChanging
+	case addr >= 0x8000 && addr < 0xA000:
+		return mode == 3 || !write && b.OAMScanDone
+	}
+	return false
+}
+
+func (b *Bus) reportBlocked(access string, addr uint16) {
+	b.BlockedAccesses++
+	if !b.ReportBlockedAccess || b.BlockedAccesses > maxBlockedReports {
+		return
+	}
+	log.Printf("Acceso bloqueado por el PPU: %s en %04X (LY=%d, modo %d)\n", access, addr, b.IO[LYRegister-0xFF00], b.IO[STATRegister-0xFF00]&0x03)
+	if b.BlockedAccesses == maxBlockedReports {
+		log.Println("Se omiten los siguientes accesos bloqueados")
+	}
+}
@@ -0,0 +1,117 @@
+package bus
+
+import "testing"
+
+// setPPU simula el estado del PPU en LCDC y STAT
+func setPPU(b *Bus, lcdOn bool, mode byte) {
+	b.IO[LCDCRegister-0xFF00] = 0x11
+	if lcdOn {
+		b.IO[LCDCRegister-0xFF00] |= 0x80
+	}
+	b.IO[STATRegister-0xFF00] = b.IO[STATRegister-0xFF00]&^0x03 | mode
+}
+
+func TestPPUModeBlocksCPUAccess(t *testing.T) {
+	b := NewBus(nil)
+	b.VRAM[0x10] = 0x12
+	b.OAM[0x10] = 0x34
+
+	cases := []struct {
+		lcdOn     bool
+		mode      byte
+		vram, oam bool // Acceso permitido
+	}{
+		{true, 0, true, true},
+		{true, 1, true, true},
+		{true, 2, true, false},
+		{true, 3, false, false},
+		{false, 3, true, true},
+	}
+	for _, c := range cases {
+		setPPU(b, c.lcdOn, c.mode)
+		b.Client = ClientCPU
+		if got := b.Read(0x8010) == 0x12; got != c.vram {
+			t.Errorf("LCD %v modo %d: lectura de VRAM permitida = %v", c.lcdOn, c.mode, got)
+		}
+		if got := b.Read(0xFE10) == 0x34; got != c.oam {
+			t.Errorf("LCD %v modo %d: lectura de OAM permitida = %v", c.lcdOn, c.mode, got)
+		}
+		b.Write(0x8011, 0xAA)
+		b.Write(0xFE11, 0xBB)
+		if got := b.VRAM[0x11] == 0xAA; got != c.vram {
+			t.Errorf("LCD %v modo %d: escritura en VRAM permitida = %v", c.lcdOn, c.mode, got)
+		}
+		if got := b.OAM[0x11] == 0xBB; got != c.oam {
+			t.Errorf("LCD %v modo %d: escritura en OAM permitida = %v", c.lcdOn, c.mode, got)
+		}
+		b.VRAM[0x11], b.OAM[0x11] = 0, 0
+	}
+
+	// El PPU siempre accede
+	setPPU(b, true, 3)
+	b.Client = ClientPPU
+	if b.Read(0x8010) != 0x12 || b.Read(0xFE10) != 0x34 {
+		t.Fatal("el PPU debería poder leer VRAM y OAM en modo 3")
+	}
+	if b.BlockedAccesses == 0 {
+		t.Fatal("no se contaron los accesos bloqueados")
+	}
+}
+
+func TestSerialTransferWithoutLink(t *testing.T) {
+	b := NewBus(nil)
+	var sent []byte
+	b.OnSerialTransfer = func(value byte) {
+		sent = append(sent, value)
+	}
+	b.Client = ClientCPU
+	b.Write(IFRegister, 0x00)
+
+	// Con reloj externo no hay transferencia
+	b.Write(SBRegister, 'A')
+	b.Write(SCRegister, 0x80)
+	for range serialTransferCycles / 4 {
+		b.TickSerial()
+	}
+	if len(sent) != 0 || b.Read(SCRegister)&0x80 == 0 {
+		t.Fatalf("transferencia con reloj externo: enviados %q, SC=%02X", sent, b.Read(SCRegister))
+	}
+
+	b.Write(SCRegister, 0x81)
+	if string(sent) != "A" {
+		t.Fatalf("enviados %q, se esperaba \"A\"", sent)
+	}
+	for range serialTransferCycles/4 - 1 {
+		b.TickSerial()
+	}
+	if b.Read(SCRegister)&0x80 == 0 || b.Read(IFRegister)&0x08 != 0 {
+		t.Fatal("la transferencia terminó antes de 8 bits a 8192 Hz")
+	}
+	b.TickSerial()
+	if sc := b.Read(SCRegister); sc != 0x01 {
+		t.Errorf("SC=%02X al terminar, se esperaba 01", sc)
+	}
+	if sb := b.Read(SBRegister); sb != 0xFF {
+		t.Errorf("SB=%02X al terminar sin cable link, se esperaba FF", sb)
+	}
+	if b.Read(IFRegister)&0x08 == 0 {
+		t.Error("no se pidió la interrupción serial")
+	}
+}
+
+func TestSTATWriteEnablesAllSources(t *testing.T) {
+	b := NewBus(nil)
+	b.IO[STATRegister-0xFF00] = 0
+	setPPU(b, true, 0)
+	b.Client = ClientCPU
+	b.Write(STATRegister, 0x48)
+	if got := b.Read(STATRegister); got != 0xF8 {
+		t.Fatalf("STAT en el ciclo de la escritura = %02X, se esperaba F8", got)
+	}
+	// El PPU cambia de modo antes de que termine el ciclo
+	setPPU(b, true, 2)
+	b.TickSTAT()
+	if got := b.Read(STATRegister); got != 0xCA {
+		t.Fatalf("STAT después de la escritura = %02X, se esperaba CA", got)
+	}
+}
@@ -0,0 +1,31 @@
+package bus
+
+// Puerto serie sin cable link conectado. Con reloj interno el DMG envía los
+// 8 bits de SB a 8192 Hz y, como no hay otra consola, recibe todos 1: al
+// terminar SB queda en 0xFF, se limpia el bit 7 de SC y se pide la
+// interrupción serial. Con reloj externo la transferencia espera un reloj
+// que nunca llega.
+
+// T-ciclos de una transferencia con reloj interno (8 bits a 8192 Hz)
+const serialTransferCycles = 8 * 512
+
+func (b *Bus) startSerialTransfer() {
+	if b.OnSerialTransfer != nil {
+		b.OnSerialTransfer(b.IO[SBRegister-0xFF00])
+	}
+	b.serialCycles = serialTransferCycles
+}
+
+// Actualiza 4 tcycles la transferencia serie
+func (b *Bus) TickSerial() {
+	if b.serialCycles == 0 {
+		return
+	}
+	b.serialCycles -= 4
+	if b.serialCycles > 0 {
+		return
+	}
+	b.IO[SBRegister-0xFF00] = 0xFF
+	b.IO[SCRegister-0xFF00] &^= 0x80
+	b.IO[IFRegister-0xFF00] |= 0x08
+}
@@ -0,0 +1,24 @@
+package bus
+
+// Rareza del DMG al escribir en STAT: durante el ciclo de la escritura el
+// registro se comporta como si se hubiera escrito 0xFF, con todas las fuentes
+// de la interrupción STAT habilitadas, y recién después toma el valor
+// escrito. Si alguna fuente está activa en ese ciclo se pide la interrupción
+// aunque el valor escrito no la habilite (Road Rash y Zerd no Densetsu
+// dependen de esto).
+
+func (b *Bus) writeSTAT(value byte) {
+	written := value & 0x78
+	b.pendingSTAT = &written
+	b.IO[STATRegister-0xFF00] |= 0x78
+}
+
+// Termina el ciclo de una escritura de la CPU en STAT: se aplica el valor
+// escrito conservando el modo y la coincidencia LY=LYC que maneja el PPU
+func (b *Bus) TickSTAT() {
+	if b.pendingSTAT == nil {
+		return
+	}
+	b.IO[STATRegister-0xFF00] = *b.pendingSTAT | (b.IO[STATRegister-0xFF00] & 0x07)
+	b.pendingSTAT = nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deybismelendez/liteboy/cartridge"
+	"github.com/deybismelendez/liteboy/video"
+)
+
+// captureName genera un nombre de archivo con el título de la ROM y la hora
+// actual, por ejemplo "TETRIS-20250102-150405.wav"
+func captureName(cart *cartridge.Cartridge, ext string) string {
+	title := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r == ' ' || r == '_':
+			return '_'
+		}
+		return -1
+	}, strings.TrimRight(cart.Title, "\x00 "))
+	if title == "" {
+		title = "liteboy"
+	}
+	return title + "-" + time.Now().Format("20060102-150405") + ext
+}
+
+// saveScreenshot guarda el último frame como PNG. Si path es una carpeta
+// existente el archivo se nombra con captureName dentro de ella. Devuelve la
+// ruta del archivo creado.
+func saveScreenshot(cart *cartridge.Cartridge, v *video.Pipeline, path string, scale int) (string, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, captureName(cart, ".png"))
+	}
+	return path, savePNG(path, v.Screenshot(scale))
+}
@@ -16,10 +16,12 @@ type Cartridge struct {
 	SGBFlag          byte
 	CartridgeType    string
 	NewLicense       string
+	NewLicenseCode   string // Código de 2 letras en 0x0144-0x0145
 	ROMSize          int
 	RAMSize          int
 	Destination      string
 	OldLicense       string
+	OldLicenseCode   byte // 0x33 indica que se usa NewLicenseCode
 	Version          byte
 	Checksum         byte
 	GlobalChecksum   uint16
@@ -33,13 +35,23 @@ type Memory interface {
 
 // NewCartridge loads and parses a Game Boy ROM cartridge
 func NewCartridge(path string) *Cartridge {
+	cart, err := LoadCartridge(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cart
+}
+
+// LoadCartridge es como NewCartridge pero devuelve el error en lugar de
+// terminar el programa, para poder recorrer muchas ROMs
+func LoadCartridge(path string) (*Cartridge, error) {
 	rom, err := os.ReadFile(path)
 
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	if len(rom) < 0x150 {
-		log.Fatal("ROM demasiado corta, inválida")
+		return nil, fmt.Errorf("%s: ROM demasiado corta, inválida", path)
 	}
 
 	cart := &Cartridge{Path: path}
@@ -66,7 +78,7 @@ func NewCartridge(path string) *Cartridge {
 		cart.Memory = &romOnly{ROM: romBanks} // ROM ONLY + RAM (+BATTERY) - No MBC
 
 	case 0x0B, 0x0C, 0x0D:
-		log.Fatalf("Tipo de cartucho MMM01 no soportado: 0x%02X\n", romType)
+		return nil, fmt.Errorf("%s: tipo de cartucho MMM01 no soportado: 0x%02X", path, romType)
 
 	case 0x0F, 0x10, 0x11, 0x12, 0x13:
 		cart.Memory = &mbc3{ROM: romBanks} // MBC3 + RTC (+RAM +BATTERY)
@@ -75,13 +87,13 @@ func NewCartridge(path string) *Cartridge {
 		cart.Memory = &mbc5{ROM: romBanks} // MBC5 (+RAM +BATTERY +RUMBLE)
 
 	case 0x20:
-		log.Fatalf("Tipo de cartucho MBC6 no soportado: 0x%02X\n", romType)
+		return nil, fmt.Errorf("%s: tipo de cartucho MBC6 no soportado: 0x%02X", path, romType)
 
 	case 0x22:
 		cart.Memory = &mbc7{ROM: romBanks} // MBC7 (Tilt sensor + EEPROM)
 
 	default:
-		log.Fatalf("Tipo de cartucho no soportado: 0x%02X. Se usará ROMOnly como fallback.\n", romType)
+		return nil, fmt.Errorf("%s: tipo de cartucho no soportado: 0x%02X", path, romType)
 	}
 
 	cart.Entry = rom[0x0100:0x0104]
@@ -89,18 +101,20 @@ func NewCartridge(path string) *Cartridge {
 	cart.Title = string(rom[0x0134:0x0143])
 	cart.ManufacturerCode = string(rom[0x013F:0x0143])
 	cart.CGBFlag = rom[0x0143]
-	cart.NewLicense = newLicCodes[string(rom[0x0144:0x0146])]
+	cart.NewLicenseCode = string(rom[0x0144:0x0146])
+	cart.NewLicense = newLicCodes[cart.NewLicenseCode]
 	cart.SGBFlag = rom[0x0146]
 	cart.CartridgeType = cartridgeTypes[romType]
 	cart.ROMSize = romSizes[rom[0x0148]]
 	cart.RAMSize = ramSizes[rom[0x0149]]
 	cart.Destination = destinationCodes[rom[0x014A]]
-	cart.OldLicense = oldLicCodes[rom[0x014B]]
+	cart.OldLicenseCode = rom[0x014B]
+	cart.OldLicense = oldLicCodes[cart.OldLicenseCode]
 	cart.Version = rom[0x014C]
 	cart.Checksum = rom[0x014D]
 	cart.GlobalChecksum = uint16(rom[0x014E])<<8 | uint16(rom[0x014F])
 
-	return cart
+	return cart, nil
 }
 
 /*func (c *Cartridge) GetROM() *[][0x4000]byte {
@@ -122,6 +136,15 @@ func (c *Cartridge) PrintHeaderInfo() {
 	fmt.Println("Global Checksum:", c.GlobalChecksum)
 }
 
+// IsNintendoLicensed indica si la licencia de la cabecera es de Nintendo,
+// con la misma comprobación que hace el boot ROM de CGB
+func (c *Cartridge) IsNintendoLicensed() bool {
+	if c.OldLicenseCode == 0x33 {
+		return c.NewLicenseCode == "01"
+	}
+	return c.OldLicenseCode == 0x01
+}
+
 func (c *Cartridge) ValidateChecksum() string {
 	var checksum byte = 0
 	for addr := uint16(0x0134); addr <= 0x014C; addr++ {
@@ -1,5 +1,7 @@
 package cpu
 
+import "github.com/deybismelendez/liteboy/ppu"
+
 // INC Register Z 0 H -
 func (cpu *CPU) incR(r *byte) {
 	val := *r
@@ -90,12 +92,14 @@ func (cpu *CPU) decHL() {
 
 // INC 16 bits - - - - suma 4 tcycles
 func (cpu *CPU) inc16(set func(uint16), value uint16) {
+	cpu.ppu.TriggerOAMBug(value, ppu.OAMBugWrite)
 	set(value + 1)
 	cpu.tick()
 }
 
 // DEC 16 bits - - - - suma 4 tcycles
 func (cpu *CPU) dec16(set func(uint16), value uint16) {
+	cpu.ppu.TriggerOAMBug(value, ppu.OAMBugWrite)
 	set(value - 1)
 	cpu.tick()
 }
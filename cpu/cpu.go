@@ -72,6 +72,13 @@ func (cpu *CPU) Write(addr uint16, value byte) {
 	cpu.tick()
 }
 
+// readIncrease lee addr en el mismo ciclo en que se incrementa o decrementa
+// el registro que la contiene, con la corrupción de la OAM de ese caso
+func (cpu *CPU) readIncrease(addr uint16) byte {
+	cpu.ppu.TriggerOAMBug(addr, ppu.OAMBugReadIncrease)
+	return cpu.bus.ReadNoOAMBug(addr)
+}
+
 func (cpu *CPU) Trace(opcode byte) {
 	log.Printf("Opcode: %02X PC=%04X SP=%04X A=%02X B=%02X C=%02X D=%02X E=%02X F=%b H=%02X L=%02X",
 		opcode, cpu.pc, cpu.sp, cpu.a, cpu.b, cpu.c, cpu.d, cpu.e, cpu.f, cpu.h, cpu.l)
@@ -1,6 +1,10 @@
 package cpu
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/deybismelendez/liteboy/ppu"
+)
 
 func (cpu *CPU) execute(opcode byte) {
 	// Decode & Execute
@@ -200,7 +204,7 @@ func (cpu *CPU) execute(opcode byte) {
 
 	case 0x2A: // LD A,(HL+)
 		hl := cpu.getHL()
-		cpu.a = cpu.bus.Read(cpu.getHL())
+		cpu.a = cpu.readIncrease(hl)
 		cpu.ldHL(hl + 1)
 		cpu.tick()
 		return
@@ -248,6 +252,7 @@ func (cpu *CPU) execute(opcode byte) {
 		return
 
 	case 0x33: // INC SP
+		cpu.ppu.TriggerOAMBug(cpu.sp, ppu.OAMBugWrite)
 		cpu.sp++
 		cpu.tick()
 		return
@@ -285,12 +290,13 @@ func (cpu *CPU) execute(opcode byte) {
 
 	case 0x3A: // LD A, (HL-)
 		hl := cpu.getHL()
-		cpu.a = cpu.bus.Read(hl)
+		cpu.a = cpu.readIncrease(hl)
 		cpu.ldHL(hl - 1)
 		cpu.tick()
 		return
 
 	case 0x3B: // DEC SP
+		cpu.ppu.TriggerOAMBug(cpu.sp, ppu.OAMBugWrite)
 		cpu.sp--
 		cpu.tick()
 		return
@@ -1,23 +1,29 @@
 package cpu
 
+import "github.com/deybismelendez/liteboy/ppu"
+
 const validFlagsMask = FlagZ | FlagN | FlagH | FlagC
 
-// suma 8 tcycles //TODO: ¿o 12?
+// suma 8 tcycles. Para el bug de la OAM el primer ciclo es una lectura con
+// incremento y el segundo solo cuenta como incremento
 func (cpu *CPU) pop16(set func(uint16)) {
-	lo := cpu.bus.Read(cpu.sp)
+	lo := cpu.readIncrease(cpu.sp)
 	cpu.sp++
 	cpu.tick()
-	hi := cpu.bus.Read(cpu.sp)
+	cpu.ppu.TriggerOAMBug(cpu.sp, ppu.OAMBugWrite)
+	hi := cpu.bus.ReadNoOAMBug(cpu.sp)
 	cpu.sp++
 	cpu.tick()
-	value := uint16(hi)<<8 | uint16(lo)
-	set(value)
-	//cpu.tick()
+	set(uint16(hi)<<8 | uint16(lo))
 }
 
-// suma 12 tcycles
+// suma 12 tcycles. Para el bug de la OAM son cuatro corrupciones de
+// escritura: el decremento del ciclo interno, el decremento y la escritura
+// del segundo ciclo y la última escritura
 func (cpu *CPU) push16(value uint16) {
+	cpu.ppu.TriggerOAMBug(cpu.sp, ppu.OAMBugWrite)
 	cpu.tick() // Internal Delay
+	cpu.ppu.TriggerOAMBug(cpu.sp, ppu.OAMBugWrite)
 	cpu.sp--
 	cpu.bus.Write(cpu.sp, byte(value>>8))
 	cpu.tick()
@@ -28,24 +34,13 @@ func (cpu *CPU) push16(value uint16) {
 
 // suma 12 tcycles
 func (cpu *CPU) pushAF() {
-	af := uint16(cpu.a)<<8 | uint16(cpu.f&validFlagsMask)
-	cpu.sp--
-	cpu.bus.Write(cpu.sp, byte(af>>8)) // MSB (A)
-	cpu.tick()
-	cpu.sp--
-	cpu.bus.Write(cpu.sp, byte(af&0xFF)) // LSB (F)
-	cpu.tick()
-	cpu.tick() // tick extra por restar 2 a sp
+	cpu.push16(uint16(cpu.a)<<8 | uint16(cpu.f&validFlagsMask))
 }
 
 // suma 8 tcycles
 func (cpu *CPU) popAF() {
-	lo := cpu.bus.Read(cpu.sp)  // LSB (F)
-	cpu.f = lo & validFlagsMask // Solo 4 bits altos válidos
-	cpu.sp++
-	cpu.tick()
-	hi := cpu.bus.Read(cpu.sp) // MSB (A)
-	cpu.a = hi
-	cpu.sp++
-	cpu.tick() // no hay tick extra por sumar
+	cpu.pop16(func(af uint16) {
+		cpu.a = byte(af >> 8)
+		cpu.f = byte(af) & validFlagsMask // Solo 4 bits altos válidos
+	})
 }
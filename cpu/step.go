@@ -41,7 +41,9 @@ func (cpu *CPU) Step() int {
 func (cpu *CPU) tick() {
 	cpu.tCycles += 4
 	cpu.bus.TickDMA()
+	cpu.bus.TickSerial()
 	cpu.ppu.Step(4)
+	cpu.bus.TickSTAT()
 	cpu.timer.Step(4)
 	cpu.apu.Step()
 	cpu.bus.Client = 0
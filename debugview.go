@@ -0,0 +1,132 @@
+//go:build !tui
+
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/deybismelendez/liteboy/ppu"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Visores de VRAM que se muestran encima del juego, recorridos con F2
+const (
+	debugViewNone = iota
+	debugViewTiles
+	debugViewMap9800
+	debugViewMap9C00
+	debugViewOAM
+	debugViewCount
+)
+
+var debugViewNames = [debugViewCount]string{"", "Tiles 8000-97FF", "Mapa 9800", "Mapa 9C00", "OAM"}
+
+// drawDebugView dibuja el visor seleccionado en lugar de la pantalla del juego
+func (liteboy *Liteboy) drawDebugView(screen *ebiten.Image) {
+	screen.Fill(color.RGBA{A: 0xFF})
+
+	var img *image.RGBA
+	scale := 2.0
+	switch liteboy.debugView {
+	case debugViewTiles:
+		img = liteboy.ppu.TilesImage()
+		scale = 3
+	case debugViewMap9800:
+		img = liteboy.ppu.TileMapImage(0x9800)
+	case debugViewMap9C00:
+		img = liteboy.ppu.TileMapImage(0x9C00)
+	case debugViewOAM:
+		img = liteboy.ppu.OAMImage()
+		scale = 3
+	}
+	view := liteboy.debugImages[liteboy.debugView]
+	if view == nil || view.Bounds() != img.Rect {
+		view = ebiten.NewImage(img.Rect.Dx(), img.Rect.Dy())
+		liteboy.debugImages[liteboy.debugView] = view
+	}
+	view.WritePixels(img.Pix)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(0, 16)
+	screen.DrawImage(view, op)
+	ebitenutil.DebugPrint(screen, debugViewNames[liteboy.debugView]+" (F2: siguiente, F3: exportar PNG)")
+
+	if liteboy.debugView == debugViewOAM {
+		// Tabla de sprites en dos columnas debajo de la imagen
+		top := 16 + int(scale)*img.Bounds().Dy() + 8
+		for _, entry := range liteboy.ppu.OAMEntries() {
+			x := (entry.Index / (ppu.OAMSpriteCount / 2)) * screen.Bounds().Dx() / 2
+			y := top + (entry.Index%(ppu.OAMSpriteCount/2))*14
+			ebitenutil.DebugPrintAt(screen, entry.String(), x, y)
+		}
+	}
+}
+
+var (
+	obj0BoxColor   = color.RGBA{R: 0xFF, B: 0xFF, A: 0xFF}
+	obj1BoxColor   = color.RGBA{G: 0xFF, B: 0xFF, A: 0xFF}
+	windowBoxColor = color.RGBA{R: 0xFF, G: 0xFF, A: 0xFF}
+)
+
+// drawOverlay marca sobre el frame los sprites con su índice OAM y el borde
+// de la window
+func (liteboy *Liteboy) drawOverlay(screen *ebiten.Image) {
+	if rect, ok := liteboy.ppu.WindowRect(); ok {
+		liteboy.strokeScreenRect(screen, rect, windowBoxColor)
+	}
+	for _, box := range liteboy.ppu.SpriteBoxes() {
+		c := obj0BoxColor
+		if box.Layer == ppu.LayerOBJ1 {
+			c = obj1BoxColor
+		}
+		liteboy.strokeScreenRect(screen, box.Rect, c)
+		x, y := liteboy.view.point(box.Rect.Min.X, box.Rect.Min.Y)
+		ebitenutil.DebugPrintAt(screen, strconv.Itoa(box.Index), int(x)+2, int(y))
+	}
+}
+
+// strokeScreenRect dibuja el borde de un rectángulo en coordenadas del Game Boy
+func (liteboy *Liteboy) strokeScreenRect(screen *ebiten.Image, rect image.Rectangle, c color.RGBA) {
+	x, y := liteboy.view.point(rect.Min.X, rect.Min.Y)
+	scale := liteboy.view.scale
+	vector.StrokeRect(screen, float32(x), float32(y),
+		float32(float64(rect.Dx())*scale), float32(float64(rect.Dy())*scale), 1, c, false)
+}
+
+// toggleLayer muestra u oculta una capa y lo informa en el log
+func (liteboy *Liteboy) toggleLayer(name string, layers ...ppu.Layer) {
+	visible := !liteboy.ppu.LayerVisible(layers[0])
+	for _, layer := range layers {
+		liteboy.ppu.SetLayerVisible(layer, visible)
+	}
+	if visible {
+		log.Println(name, "visible")
+	} else {
+		log.Println(name, "oculto")
+	}
+}
+
+// hiddenLayersText resume las capas ocultas para el texto en pantalla
+func (liteboy *Liteboy) hiddenLayersText() string {
+	var hidden []string
+	if !liteboy.ppu.LayerVisible(ppu.LayerBG) {
+		hidden = append(hidden, "fondo")
+	}
+	if !liteboy.ppu.LayerVisible(ppu.LayerWindow) {
+		hidden = append(hidden, "window")
+	}
+	if !liteboy.ppu.LayerVisible(ppu.LayerOBJ0) {
+		hidden = append(hidden, "sprites")
+	}
+	if len(hidden) == 0 {
+		return ""
+	}
+	return "\nOculto: " + strings.Join(hidden, ", ")
+}
@@ -0,0 +1,194 @@
+package gbs
+
+// Formato GBS (Game Boy Sound System):
+// https://ocremix.org/info/GBS_Format_Specification
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/deybismelendez/liteboy/cartridge"
+)
+
+const (
+	headerSize = 0x70
+	// Dirección mínima de carga: 0x0000-0x03FF queda para el driver
+	minLoadAddress = 0x0400
+	// La CPU comienza a ejecutar en 0x0100 después del Boot ROM
+	driverAddress = 0x0100
+)
+
+type Header struct {
+	Version     byte
+	Songs       byte
+	FirstSong   byte // 1 = primera canción
+	LoadAddress uint16
+	InitAddress uint16
+	PlayAddress uint16
+	StackPtr    uint16
+	TimerModulo byte // TMA
+	TimerCtrl   byte // TAC, si el bit 2 está activo play se llama con la interrupción del timer
+	Title       string
+	Author      string
+	Copyright   string
+}
+
+// File es un archivo GBS cargado en memoria
+type File struct {
+	Header
+	Data []byte // Código y datos que se cargan en LoadAddress
+}
+
+// UsesTimer indica si play se llama con la interrupción del timer en lugar de VBlank
+func (h *Header) UsesTimer() bool {
+	return h.TimerCtrl&0x04 != 0
+}
+
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*File, error) {
+	if len(data) < headerSize || string(data[0:3]) != "GBS" {
+		return nil, errors.New("gbs: cabecera inválida")
+	}
+	h := Header{
+		Version:     data[0x03],
+		Songs:       data[0x04],
+		FirstSong:   data[0x05],
+		LoadAddress: binary.LittleEndian.Uint16(data[0x06:]),
+		InitAddress: binary.LittleEndian.Uint16(data[0x08:]),
+		PlayAddress: binary.LittleEndian.Uint16(data[0x0A:]),
+		StackPtr:    binary.LittleEndian.Uint16(data[0x0C:]),
+		TimerModulo: data[0x0E],
+		TimerCtrl:   data[0x0F],
+		Title:       headerString(data[0x10:0x30]),
+		Author:      headerString(data[0x30:0x50]),
+		Copyright:   headerString(data[0x50:0x70]),
+	}
+	if h.Version != 1 {
+		return nil, fmt.Errorf("gbs: versión %d no soportada", h.Version)
+	}
+	if h.Songs == 0 {
+		return nil, errors.New("gbs: el archivo no contiene canciones")
+	}
+	if h.LoadAddress < minLoadAddress || h.LoadAddress >= 0x8000 {
+		return nil, fmt.Errorf("gbs: dirección de carga %04X fuera de rango", h.LoadAddress)
+	}
+	if h.FirstSong == 0 || h.FirstSong > h.Songs {
+		h.FirstSong = 1
+	}
+	return &File{Header: h, Data: data[headerSize:]}, nil
+}
+
+func headerString(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// Cartridge construye un cartucho que reproduce la canción indicada
+// (1..Songs) al ejecutarse desde 0x0100
+func (f *File) Cartridge(song int) (*cartridge.Cartridge, error) {
+	if song < 1 || song > int(f.Songs) {
+		return nil, fmt.Errorf("gbs: la canción %d no existe (1-%d)", song, f.Songs)
+	}
+	image := make([]byte, int(f.LoadAddress)+len(f.Data))
+	copy(image[f.LoadAddress:], f.Data)
+	mem := &memory{ROM: make([][0x4000]byte, (len(image)+0x3FFF)/0x4000), bank: 1}
+	if len(mem.ROM) < 2 {
+		mem.ROM = append(mem.ROM, [0x4000]byte{})
+	}
+	for i := range mem.ROM {
+		copy(mem.ROM[i][:], image[min(i*0x4000, len(image)):])
+	}
+	f.writeDriver(&mem.ROM[0], byte(song-1))
+
+	return &cartridge.Cartridge{
+		Title:         f.Title,
+		CartridgeType: "GBS",
+		Memory:        mem,
+	}, nil
+}
+
+// writeDriver coloca en el banco 0 el código que prepara la canción, llama
+// a init y luego espera en HALT a que las interrupciones llamen a play
+func (f *File) writeDriver(rom *[0x4000]byte, song byte) {
+	// RST 00-38 saltan a LoadAddress + vector
+	for vector := uint16(0); vector < 0x40; vector += 8 {
+		putCode(rom, vector, 0xC3, lo(f.LoadAddress+vector), hi(f.LoadAddress+vector)) // JP
+	}
+	// Vectores de VBlank (0x40) y Timer (0x50)
+	for _, vector := range []uint16{0x40, 0x50} {
+		putCode(rom, vector,
+			0xCD, lo(f.PlayAddress), hi(f.PlayAddress), // CALL play
+			0xD9, // RETI
+		)
+	}
+
+	ie := byte(0x01) // VBlank
+	if f.UsesTimer() {
+		ie = 0x04 // Timer
+	}
+	putCode(rom, driverAddress,
+		0xF3,                                 // DI
+		0x31, lo(f.StackPtr), hi(f.StackPtr), // LD SP, stack
+		0x3E, f.TimerModulo, 0xE0, 0x06, // LDH (TMA), tma
+		0x3E, f.TimerCtrl&0x07, 0xE0, 0x07, // LDH (TAC), tac
+		0x3E, song, // LD A, canción
+		0xCD, lo(f.InitAddress), hi(f.InitAddress), // CALL init
+		0x3E, ie, 0xE0, 0xFF, // LDH (IE), ie
+		0xAF, 0xE0, 0x0F, // LDH (IF), 0
+		0xFB,       // EI
+		0x76,       // loop: HALT
+		0x18, 0xFD, // JR loop
+	)
+}
+
+func putCode(rom *[0x4000]byte, addr uint16, code ...byte) {
+	copy(rom[addr:], code)
+}
+
+func lo(v uint16) byte { return byte(v) }
+func hi(v uint16) byte { return byte(v >> 8) }
+
+// memory mapea el código GBS como ROM con bancos seleccionables en
+// 0x2000-0x3FFF y RAM en 0xA000-0xBFFF
+type memory struct {
+	ROM  [][0x4000]byte
+	RAM  [0x2000]byte
+	bank int
+}
+
+func (m *memory) Read(addr uint16) byte {
+	switch {
+	case addr < 0x4000:
+		return m.ROM[0][addr]
+	case addr < 0x8000:
+		return m.ROM[m.bank][addr-0x4000]
+	case addr >= 0xA000 && addr < 0xC000:
+		return m.RAM[addr-0xA000]
+	}
+	return 0xFF
+}
+
+func (m *memory) Write(addr uint16, value byte) {
+	switch {
+	case addr >= 0x2000 && addr < 0x4000:
+		bank := int(value)
+		if bank == 0 {
+			bank = 1
+		}
+		m.bank = bank % len(m.ROM)
+	case addr >= 0xA000 && addr < 0xC000:
+		m.RAM[addr-0xA000] = value
+	}
+}
@@ -0,0 +1,89 @@
+package gbs
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/bus"
+	"github.com/deybismelendez/liteboy/cpu"
+	"github.com/deybismelendez/liteboy/ppu"
+	"github.com/deybismelendez/liteboy/timer"
+)
+
+// testGBS arma un GBS cuyo init guarda la canción en 0xC000 y cuyo play
+// incrementa el contador en 0xC001
+func testGBS(tma, tac byte) []byte {
+	data := make([]byte, headerSize)
+	copy(data, "GBS")
+	data[0x03] = 1
+	data[0x04] = 3
+	data[0x05] = 2
+	binary.LittleEndian.PutUint16(data[0x06:], 0x0400) // load
+	binary.LittleEndian.PutUint16(data[0x08:], 0x0400) // init
+	binary.LittleEndian.PutUint16(data[0x0A:], 0x0404) // play
+	binary.LittleEndian.PutUint16(data[0x0C:], 0xDFFF) // stack
+	data[0x0E] = tma
+	data[0x0F] = tac
+	copy(data[0x10:], "Test Song")
+	copy(data[0x30:], "Liteboy")
+	return append(data,
+		0xEA, 0x00, 0xC0, 0xC9, // init: LD (C000), A; RET
+		0x21, 0x01, 0xC0, 0x34, 0xC9, // play: LD HL, C001; INC (HL); RET
+	)
+}
+
+func runSong(t *testing.T, data []byte, song, frames int) *bus.Bus {
+	t.Helper()
+	file, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cart, err := file.Cartridge(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gameBus := bus.NewBus(cart)
+	gamePPU := ppu.NewPPU(gameBus)
+	gameTimer := timer.NewTimer(gameBus)
+	gameAPU := apu.NewAPU(gameBus, nil)
+	gameCPU := cpu.NewCPU(gameBus, gameTimer, gamePPU, gameAPU)
+	for cycles := 0; cycles < frames*70224; {
+		cycles += gameCPU.Step()
+	}
+	return gameBus
+}
+
+func TestParseHeader(t *testing.T) {
+	file, err := Parse(testGBS(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Songs != 3 || file.FirstSong != 2 || file.Title != "Test Song" || file.Author != "Liteboy" {
+		t.Fatalf("cabecera mal leída: %+v", file.Header)
+	}
+	if file.UsesTimer() {
+		t.Fatal("TAC=0 no debe usar el timer")
+	}
+	if _, err := file.Cartridge(4); err == nil {
+		t.Fatal("se esperaba error con una canción inexistente")
+	}
+}
+
+func TestPlayCalledOnVBlank(t *testing.T) {
+	b := runSong(t, testGBS(0, 0), 3, 10)
+	if got := b.Read(0xC000); got != 2 {
+		t.Fatalf("init recibió la canción %d, se esperaba 2", got)
+	}
+	if got := b.Read(0xC001); got < 8 || got > 10 {
+		t.Fatalf("play se llamó %d veces en 10 frames", got)
+	}
+}
+
+func TestPlayCalledOnTimer(t *testing.T) {
+	// 262144 Hz / (256 - 0xC0) = 4096 llamadas por segundo
+	b := runSong(t, testGBS(0xC0, 0x05), 1, 1)
+	if got := b.Read(0xC001); got < 60 || got > 70 {
+		t.Fatalf("play se llamó %d veces en un frame", got)
+	}
+}
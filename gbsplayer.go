@@ -0,0 +1,156 @@
+//go:build !tui
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/gbs"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// gbsPlayer reproduce un archivo GBS; cada canción corre en una máquina nueva
+type gbsPlayer struct {
+	file    *gbs.File
+	track   int
+	machine *machine
+	cycles  int
+	// Grabación de audio activada con F9, continúa al cambiar de canción
+	wav wavCapture
+}
+
+func newGBSPlayer(file *gbs.File, track int, playback apu.AudioSink) (*gbsPlayer, error) {
+	player := &gbsPlayer{file: file, wav: wavCapture{playback: playback}}
+	if err := player.setTrack(track); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+// setTrack reinicia la máquina con la canción indicada (1..Songs)
+func (p *gbsPlayer) setTrack(track int) error {
+	cart, err := p.file.Cartridge(track)
+	if err != nil {
+		return err
+	}
+	p.track = track
+	p.machine = newMachine(cart, p.wav.sink())
+	p.cycles = 0
+	return nil
+}
+
+func (p *gbsPlayer) Update() error {
+	for p.cycles < CyclesPerFrame {
+		p.cycles += p.machine.cpu.Step()
+	}
+	p.cycles -= CyclesPerFrame
+
+	songs := int(p.file.Songs)
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyRight):
+		return p.setTrack(p.track%songs + 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyLeft):
+		return p.setTrack((p.track+songs-2)%songs + 1)
+	case inpututil.IsKeyJustPressed(ebiten.KeyF9):
+		p.wav.toggle(p.machine.apu, gbsCaptureName(p.file, p.track))
+	}
+	return nil
+}
+
+func (p *gbsPlayer) Draw(screen *ebiten.Image) {
+	msg := fmt.Sprintf("LiteBoy GBS Player\n\n%s\n%s\n%s\n\nTrack %d/%d\n\n<- / -> cambiar de canción\nF9 grabar WAV",
+		p.file.Title, p.file.Author, p.file.Copyright, p.track, p.file.Songs)
+	if p.wav.recorder != nil {
+		msg += "\nREC " + p.wav.recorder.Path
+	}
+	ebitenutil.DebugPrint(screen, msg)
+}
+
+func (p *gbsPlayer) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	return ScreenWidth * 2, ScreenHeight * 2
+}
+
+// gbsCaptureName genera un nombre de grabación como "Titulo-03.wav"
+func gbsCaptureName(file *gbs.File, track int) string {
+	title := strings.ReplaceAll(file.Title, " ", "_")
+	if title == "" {
+		title = "gbs"
+	}
+	return fmt.Sprintf("%s-%02d.wav", title, track)
+}
+
+// runGBSCommand implementa "liteboy gbs <archivo.gbs> [opciones]"
+func runGBSCommand(args []string) {
+	flags := flag.NewFlagSet("liteboy gbs", flag.ExitOnError)
+	track := flags.Int("track", 0, "canción a reproducir (por defecto la indicada en el archivo)")
+	headless := flags.Bool("headless", false, "exporta sin ventana ni audio")
+	frames := flags.Int("frames", 60*60, "frames a emular en modo headless")
+	wavPath := flags.String("wav", "", "graba el audio en el archivo WAV indicado")
+	wavStems := flags.Bool("stems", false, "graba además un WAV mono por canal (chan1-chan4)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: liteboy gbs <archivo.gbs> [opciones]")
+		flags.PrintDefaults()
+	}
+	args = parseArgs(flags, args)
+	if len(args) < 1 {
+		flags.Usage()
+		return
+	}
+
+	file, err := gbs.Load(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *track == 0 {
+		*track = int(file.FirstSong)
+	}
+
+	if *headless {
+		if *wavPath == "" {
+			log.Fatal("el modo headless requiere --wav")
+		}
+		recorder, err := apu.NewWAVRecorder(*wavPath, *wavStems)
+		if err != nil {
+			log.Fatal(err)
+		}
+		player, err := newGBSPlayer(file, *track, recorder)
+		if err != nil {
+			recorder.Close()
+			log.Fatal(err)
+		}
+		player.machine.runFrames(*frames)
+		if err := recorder.Close(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	sink, err := newEbitenSink()
+	if err != nil {
+		log.Fatal("error al crear audio player:", err)
+	}
+	defer sink.Close()
+	player, err := newGBSPlayer(file, *track, sink)
+	if err != nil {
+		log.Fatal(err)
+	}
+	player.wav.stems = *wavStems
+	if *wavPath != "" {
+		player.wav.start(player.machine.apu, *wavPath)
+	}
+
+	ebiten.SetWindowSize(ScreenWidth*Scale, ScreenHeight*Scale)
+	ebiten.SetWindowTitle("LiteBoy GBS Player")
+	ebiten.SetTPS(60)
+	err = ebiten.RunGame(player)
+	player.wav.stop(player.machine.apu)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/cartridge"
+	"github.com/deybismelendez/liteboy/ppu"
+)
+
+// Carpeta donde se guardan el frame obtenido y la imagen de diferencias de
+// los tests que fallan
+const goldenOutputDir = "golden_output"
+
+// goldenTest es un test ROM cuyo resultado se compara con una captura de
+// referencia. Se emula hasta que el ROM ejecuta LD B,B o hasta frames.
+type goldenTest struct {
+	rom       string
+	reference string
+	frames    int
+}
+
+var goldenTests = map[string]goldenTest{
+	"dmg-acid2": {"roms/dmg-acid2/dmg-acid2.gb", "roms/dmg-acid2/reference-dmg.png", 60},
+	// Sin LD B,B: la pantalla queda fija después de unos frames
+	"mooneye/sprite_priority": {"roms/mooneye/manual-only/sprite_priority.gb", "roms/mooneye/manual-only/sprite_priority-expected.png", 60},
+}
+
+// Tests de mealybug-tearoom que tienen captura de referencia de DMG; los de
+// la window están en windowTests
+var mealybugTests = []string{
+	"m3_bgp_change",
+	"m3_bgp_change_sprites",
+	"m3_lcdc_bg_en_change",
+	"m3_lcdc_bg_map_change",
+	"m3_lcdc_obj_en_change",
+	"m3_lcdc_obj_en_change_variant",
+	"m3_lcdc_obj_size_change",
+	"m3_lcdc_obj_size_change_scx",
+	"m3_lcdc_tile_sel_change",
+	"m3_obp0_change",
+	"m3_scx_high_5_bits",
+	"m3_scx_low_3_bits",
+	"m3_scy_change",
+}
+
+// Tests de mealybug-tearoom de la window: el latch de WY, WX entre 0 y 6
+// (m3_window_timing_wx_0 comprueba wx0Cut) y los cambios de WX y LCDC a mitad
+// de línea
+var windowTests = []string{
+	"m2_win_en_toggle",
+	"m3_lcdc_tile_sel_win_change",
+	"m3_lcdc_win_en_change_multiple",
+	"m3_lcdc_win_en_change_multiple_wx",
+	"m3_lcdc_win_map_change",
+	"m3_window_timing",
+	"m3_window_timing_wx_0",
+	"m3_wx_4_change",
+	"m3_wx_4_change_sprites",
+	"m3_wx_5_change",
+	"m3_wx_6_change",
+}
+
+func init() {
+	for _, name := range mealybugTests {
+		goldenTests["mealybug/"+name] = mealybugTest(name)
+	}
+}
+
+func mealybugTest(name string) goldenTest {
+	return goldenTest{
+		rom:       "roms/mealybug/" + name + ".gb",
+		reference: "roms/mealybug/expected/DMG-blob/" + name + ".png",
+		frames:    60,
+	}
+}
+
+func TestGoldenImages(t *testing.T) {
+	for name, test := range goldenTests {
+		t.Run(name, func(t *testing.T) {
+			if err := runGoldenTest(name, test); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestWindowROMs(t *testing.T) {
+	for _, name := range windowTests {
+		t.Run(name, func(t *testing.T) {
+			if err := runGoldenTest("mealybug/"+name, mealybugTest(name)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// runGoldenTest emula el ROM y compara los tonos de la pantalla con la
+// referencia. Si no coinciden guarda el frame y las diferencias en
+// goldenOutputDir.
+func runGoldenTest(name string, test goldenTest) error {
+	reference, err := loadReferenceShades(test.reference)
+	if err != nil {
+		return err
+	}
+	m := newMachine(cartridge.NewCartridge(test.rom), apu.NullSink{})
+	runUntilBreakpoint(m, test.frames)
+
+	mismatches := 0
+	for i, shade := range m.ppu.Shades {
+		if shade != reference[i] {
+			mismatches++
+		}
+	}
+	if mismatches == 0 {
+		return nil
+	}
+
+	base := filepath.Join(goldenOutputDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		return err
+	}
+	if err := savePNG(base+"-actual.png", m.ppu.Image()); err != nil {
+		return err
+	}
+	if err := savePNG(base+"-diff.png", shadeDiff(m.ppu.Shades, reference)); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d píxeles distintos a %s, ver %s-diff.png", mismatches, test.reference, base)
+}
+
+// runUntilBreakpoint emula hasta que se ejecuta LD B,B (0x40), que los test
+// ROMs usan para avisar que la pantalla está lista, o hasta frames frames.
+// Después del LD B,B se sigue hasta que termina el frame, para no comparar
+// una pantalla dibujada a medias.
+func runUntilBreakpoint(m *machine, frames int) {
+	for range frames {
+		for !m.ppu.FrameReady() {
+			opcode := m.cpu.GetOpcode()
+			m.cpu.Step()
+			if opcode == 0x40 {
+				for !m.ppu.FrameReady() {
+					m.cpu.Step()
+				}
+				return
+			}
+		}
+	}
+}
+
+// loadReferenceShades lee una captura de referencia y convierte cada píxel al
+// tono del DMG más cercano según su brillo, así la comparación no depende de
+// la paleta con que se hizo la captura
+func loadReferenceShades(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() != ppu.ScreenWidth || bounds.Dy() != ppu.ScreenHeight {
+		return nil, fmt.Errorf("%s: se esperaba una imagen de %dx%d, es de %dx%d", path, ppu.ScreenWidth, ppu.ScreenHeight, bounds.Dx(), bounds.Dy())
+	}
+	shades := make([]byte, ppu.ScreenWidth*ppu.ScreenHeight)
+	for y := range ppu.ScreenHeight {
+		for x := range ppu.ScreenWidth {
+			grey := color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray)
+			shades[y*ppu.ScreenWidth+x] = byte((255 - int(grey.Y) + 42) / 85)
+		}
+	}
+	return shades, nil
+}
+
+// shadeDiff dibuja en gris tenue los píxeles que coinciden y en rojo los
+// distintos
+func shadeDiff(actual, reference []byte) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, ppu.ScreenWidth, ppu.ScreenHeight))
+	for i, shade := range actual {
+		c := color.RGBA{R: 0xFF, A: 0xFF}
+		if shade == reference[i] {
+			grey := 0xFF - shade*0x20
+			c = color.RGBA{R: grey, G: grey, B: grey, A: 0xFF}
+		}
+		img.SetRGBA(i%ppu.ScreenWidth, i/ppu.ScreenWidth, c)
+	}
+	return img
+}
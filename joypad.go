@@ -0,0 +1,34 @@
+package main
+
+// Botones del joypad como máscara de bits. Los de dirección ocupan los bits
+// 0-3 y los de acción los bits 4-7, en el mismo orden que en P1.
+const (
+	buttonRight = 1 << iota
+	buttonLeft
+	buttonUp
+	buttonDown
+	buttonA
+	buttonB
+	buttonSelect
+	buttonStart
+)
+
+// updateJoypad escribe en P1 (0xFF00) los botones presionados de la fila
+// que seleccionó el juego. Se llama después de cada instrucción.
+func (m *machine) updateJoypad(pressed byte) {
+	// Leer el valor del registro P1 (0xFF00)
+	p1 := m.bus.Read(0xFF00)
+
+	var input byte = 0x0F // bits 0-3: todos sueltos (1 = no presionado)
+
+	// Bit 4: dirección (0=activado), Bit 5: botones
+	if p1&(1<<4) == 0 {
+		input &^= pressed & 0x0F
+	}
+	if p1&(1<<5) == 0 {
+		input &^= pressed >> 4
+	}
+
+	// Escribir bits 0-3 en el registro FF00 sin tocar bits 4-7
+	m.bus.Write(0xFF00, (p1&0xF0)|input)
+}
@@ -1,46 +1,152 @@
+//go:build !tui
+
 package main
 
 import (
 	"fmt"
+	"log"
+	"math"
+	"slices"
 
-	"github.com/deybismelendez/liteboy/bus"
-	"github.com/deybismelendez/liteboy/cpu"
+	"github.com/deybismelendez/liteboy/cartridge"
 	"github.com/deybismelendez/liteboy/ppu"
+	"github.com/deybismelendez/liteboy/video"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-)
-
-const (
-	ScreenWidth  = 160
-	ScreenHeight = 144
-	Scale        = 4
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 type Liteboy struct {
-	cpu         *cpu.CPU
-	ppu         *ppu.PPU
-	bus         *bus.Bus
+	*machine
 	cycles      int
 	targetTPS   int
 	tpsMode     []int
 	fastForward int
 	image       *ebiten.Image
+	// Grabación de audio activada con F9
+	wav wavCapture
+	// Archivo de la grabación VGM activada con F10
+	vgmPath string
+	// Archivo de la transcripción MIDI activada con F8
+	midiPath string
+	// Visor de VRAM mostrado con F2 (debugViewNone si ninguno) y la imagen
+	// de cada visor, que se actualiza en cada Draw
+	debugView   int
+	debugImages [debugViewCount]*ebiten.Image
+	// Rectángulos de sprites y window dibujados sobre el frame (F1)
+	overlay bool
+	// Paleta seleccionada con F7 (índice en paletteCycle, -1 si es propia)
+	palette int
+	// Escala de las capturas guardadas con P
+	screenshotScale int
+	// Formato (extensión) y escala de las grabaciones de video iniciadas con R
+	recordFormat string
+	recordScale  int
+	// Solo escala la pantalla por números enteros al ajustarla a la ventana
+	integerScale bool
+	// Posición y escala de la pantalla del Game Boy en el último Draw
+	view viewport
+}
+
+// viewport ubica la pantalla del Game Boy dentro de la ventana
+type viewport struct {
+	x, y, scale float64
+}
+
+// fitViewport centra la pantalla en un área de width×height conservando la
+// proporción; con integer la escala se redondea hacia abajo (mínimo 1)
+func fitViewport(width, height int, integer bool) viewport {
+	scale := min(float64(width)/ScreenWidth, float64(height)/ScreenHeight)
+	if integer {
+		scale = max(math.Floor(scale), 1)
+	}
+	return viewport{
+		x:     (float64(width) - ScreenWidth*scale) / 2,
+		y:     (float64(height) - ScreenHeight*scale) / 2,
+		scale: scale,
+	}
+}
+
+// point convierte coordenadas del Game Boy a coordenadas de la ventana
+func (v viewport) point(x, y int) (float64, float64) {
+	return v.x + float64(x)*v.scale, v.y + float64(y)*v.scale
+}
+
+// Paletas que se recorren con F7
+var paletteCycle = append([]string{"auto"}, ppu.PaletteNames...)
+
+// runWindow juega la ROM en una ventana de Ebitengine con audio
+func runWindow(cart *cartridge.Cartridge, opts frontendOptions) {
+	sink, err := newEbitenSink()
+	if err != nil {
+		log.Fatal("error al crear audio player:", err)
+	}
+	defer sink.Close()
+	game := NewLiteboy(newMachine(cart, sink))
+	game.wav.stems = opts.wavStems
+	game.setPalette(opts.paletteSpec, opts.palettes)
+	game.video.Ghosting = opts.ghosting
+	game.video.Grid = opts.grid
+	game.video.Filter = opts.filter
+	game.video.Scale = opts.scale
+	game.integerScale = opts.integerScale
+	game.screenshotScale = opts.screenshotScale
+	game.recordFormat = opts.recordFormat
+	game.recordScale = opts.recordScale
+	if opts.recordPath != "" {
+		if err := game.startRecording(opts.recordPath, opts.recordScale); err != nil {
+			log.Fatal(err)
+		}
+	}
+	game.bus.ReportBlockedAccess = opts.reportAccess
+	if opts.wavPath != "" {
+		game.wav.start(game.apu, opts.wavPath)
+	}
+	if opts.vgmPath != "" {
+		game.startVGM(opts.vgmPath)
+	}
+	if opts.midiPath != "" {
+		game.startMIDI(opts.midiPath)
+	}
+
+	// Configurar ventana y correr el loop de Ebiten
+	ebiten.SetWindowSize(ScreenWidth*opts.scale, ScreenHeight*opts.scale)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetFullscreen(opts.fullscreen)
+	ebiten.SetWindowTitle("LiteBoy Emulator")
+	ebiten.SetTPS(60)
+	err = ebiten.RunGame(game)
+	game.wav.stop(game.apu)
+	game.stopRecording()
+	game.stopVGM()
+	game.stopMIDI()
+	if opts.reportAccess {
+		log.Println("Accesos bloqueados por el PPU:", game.bus.BlockedAccesses)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func NewLiteboy(cpu *cpu.CPU, ppu *ppu.PPU, bus *bus.Bus) *Liteboy {
+func NewLiteboy(m *machine) *Liteboy {
 	return &Liteboy{
-		cpu:         cpu,
-		ppu:         ppu,
-		bus:         bus,
+		machine:     m,
 		image:       ebiten.NewImage(ScreenWidth, ScreenHeight),
-		tpsMode:     []int{70224, 70224 * 2, 70224 * 3, 70224 * 4},
+		tpsMode:     []int{CyclesPerFrame, CyclesPerFrame * 2, CyclesPerFrame * 3, CyclesPerFrame * 4},
 		fastForward: 1,
+		wav:         wavCapture{playback: m.apu.Sink()},
 	}
 }
 
 func (liteboy *Liteboy) Update() error {
 	for liteboy.cycles < liteboy.tpsMode[liteboy.targetTPS] {
 		liteboy.cycles += liteboy.cpu.Step()
+		// En avance rápido se emulan varios frames por Update; cada uno pasa
+		// por el postproceso y la grabación
+		if liteboy.ppu.FrameReady() {
+			liteboy.endFrame()
+		}
 		// Pasamos ciclos reales transcurridos
 		liteboy.handleGamepad()
 	}
@@ -48,25 +154,53 @@ func (liteboy *Liteboy) Update() error {
 	liteboy.handleKeyboard()
 
 	// Renderizado
-	liteboy.image.WritePixels(liteboy.ppu.Framebuffer)
+	frame := liteboy.video.Image()
+	if liteboy.image.Bounds() != frame.Rect {
+		liteboy.image = ebiten.NewImage(frame.Rect.Dx(), frame.Rect.Dy())
+	}
+	liteboy.image.WritePixels(frame.Pix)
 	liteboy.cycles -= liteboy.tpsMode[liteboy.targetTPS]
 
 	return nil
 }
 
 func (liteboy *Liteboy) Draw(screen *ebiten.Image) {
-	// Escalar la imagen a la ventana (multiplicando el tamaño)
+	// Ajustar la imagen a la ventana; la imagen puede venir ya escalada por
+	// el filtro
+	liteboy.view = fitViewport(screen.Bounds().Dx(), screen.Bounds().Dy(), liteboy.integerScale)
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Scale(Scale, Scale)
+	scale := liteboy.view.scale * ScreenWidth / float64(liteboy.image.Bounds().Dx())
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(liteboy.view.x, liteboy.view.y)
 	screen.DrawImage(liteboy.image, op)
+	if liteboy.debugView != debugViewNone {
+		liteboy.drawDebugView(screen)
+		return
+	}
+	if liteboy.overlay {
+		liteboy.drawOverlay(screen)
+	}
 
 	// Mostrar FPS en pantalla
 	msg := fmt.Sprintf("LiteBoy Emulator - Press ESC to quit\nFPS: %.2f TPS: %.2f Target TPS: %d", ebiten.ActualFPS(), ebiten.ActualTPS()*float64(liteboy.tpsMode[liteboy.targetTPS])/60, liteboy.tpsMode[liteboy.targetTPS])
+	if liteboy.wav.recorder != nil {
+		msg += "\nREC " + liteboy.wav.recorder.Path
+	}
+	if liteboy.recording != nil {
+		msg += "\nREC " + liteboy.recording.path
+	}
+	if liteboy.apu.RecordingVGM() {
+		msg += "\nREC " + liteboy.vgmPath
+	}
+	if liteboy.apu.RecordingMIDI() {
+		msg += "\nREC " + liteboy.midiPath
+	}
+	msg += liteboy.hiddenLayersText()
 	ebitenutil.DebugPrint(screen, msg)
 }
 
 func (liteboy *Liteboy) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return ScreenWidth * Scale, ScreenHeight * Scale
+	return outsideWidth, outsideHeight
 }
 
 func (liteboy *Liteboy) handleKeyboard() {
@@ -75,48 +209,146 @@ func (liteboy *Liteboy) handleKeyboard() {
 	} else {
 		liteboy.targetTPS = 0
 	}
-}
-
-func (liteboy *Liteboy) handleGamepad() {
-	// Leer el valor del registro P1 (0xFF00)
-	p1 := liteboy.bus.Read(0xFF00)
-
-	// Bit 4: dirección (0=activado), Bit 5: botones
-	directionKeys := (p1 & (1 << 4)) == 0
-	buttonKeys := (p1 & (1 << 5)) == 0
-
-	var input byte = 0x0F // bits 0-3: todos presionados (1 = no presionado)
-
-	if directionKeys {
-		if ebiten.IsKeyPressed(ebiten.KeyRight) {
-			input &= ^byte(1 << 0) // Bit 0 - Derecha
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyLeft) {
-			input &= ^byte(1 << 1) // Bit 1 - Izquierda
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyUp) {
-			input &= ^byte(1 << 2) // Bit 2 - Arriba
+	if inpututil.IsKeyJustPressed(ebiten.KeyF1) {
+		liteboy.overlay = !liteboy.overlay
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+		liteboy.toggleLayer("Fondo", ppu.LayerBG)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		liteboy.toggleLayer("Window", ppu.LayerWindow)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF6) {
+		liteboy.toggleLayer("Sprites", ppu.LayerOBJ0, ppu.LayerOBJ1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF2) {
+		liteboy.debugView = (liteboy.debugView + 1) % debugViewCount
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		dir := captureName(liteboy.cart, "-vram")
+		if err := saveVRAMImages(liteboy.ppu, dir); err != nil {
+			log.Println("error al exportar la VRAM:", err)
+		} else {
+			log.Println("VRAM exportada en", dir)
 		}
-		if ebiten.IsKeyPressed(ebiten.KeyDown) {
-			input &= ^byte(1 << 3) // Bit 3 - Abajo
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF7) {
+		name := paletteCycle[(liteboy.palette+1)%len(paletteCycle)]
+		palettes, err := loadPalettes(liteboy.cart, name)
+		if err != nil {
+			log.Println(err)
+		} else {
+			liteboy.setPalette(name, palettes)
+			log.Println("Paleta:", name)
 		}
 	}
-
-	if buttonKeys {
-		if ebiten.IsKeyPressed(ebiten.KeyZ) {
-			input &= ^byte(1 << 0) // Bit 0 - A
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		path, err := saveScreenshot(liteboy.cart, liteboy.video, ".", liteboy.screenshotScale)
+		if err != nil {
+			log.Println("error al guardar la captura:", err)
+		} else {
+			log.Println("Captura guardada en", path)
 		}
-		if ebiten.IsKeyPressed(ebiten.KeyX) {
-			input &= ^byte(1 << 1) // Bit 1 - B
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		if liteboy.recording != nil {
+			liteboy.stopRecording()
+		} else if err := liteboy.startRecording(captureName(liteboy.cart, "."+liteboy.recordFormat), liteboy.recordScale); err != nil {
+			log.Println("error al iniciar la grabación de video:", err)
 		}
-		if ebiten.IsKeyPressed(ebiten.KeySpace) {
-			input &= ^byte(1 << 2) // Bit 2 - Select
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		names := video.FilterNames()
+		liteboy.video.Filter = names[(slices.Index(names, liteboy.video.Filter)+1)%len(names)]
+		log.Println("Filtro:", liteboy.video.Filter)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		liteboy.wav.toggle(liteboy.apu, captureName(liteboy.cart, ".wav"))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF10) {
+		if liteboy.apu.RecordingVGM() {
+			liteboy.stopVGM()
+		} else {
+			liteboy.startVGM(captureName(liteboy.cart, ".vgm"))
 		}
-		if ebiten.IsKeyPressed(ebiten.KeyEnter) {
-			input &= ^byte(1 << 3) // Bit 3 - Start
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF8) {
+		if liteboy.apu.RecordingMIDI() {
+			liteboy.stopMIDI()
+		} else {
+			liteboy.startMIDI(captureName(liteboy.cart, ".mid"))
 		}
 	}
+}
+
+// setPalette aplica las paletas y recuerda su posición en paletteCycle
+func (liteboy *Liteboy) setPalette(name string, palettes ppu.Palettes) {
+	liteboy.palette = slices.Index(paletteCycle, name)
+	liteboy.ppu.SetPalettes(palettes)
+}
 
-	// Escribir bits 0-3 en el registro FF00 sin tocar bits 4-7
-	liteboy.bus.Write(0xFF00, (p1&0xF0)|input)
+// startVGM registra las escrituras en los registros de sonido hasta stopVGM
+func (liteboy *Liteboy) startVGM(path string) {
+	liteboy.vgmPath = path
+	liteboy.apu.StartVGM()
+	log.Println("Grabando VGM en", path)
+}
+
+func (liteboy *Liteboy) stopVGM() {
+	vgm := liteboy.apu.StopVGM()
+	if vgm == nil {
+		return
+	}
+	if err := vgm.Save(liteboy.vgmPath); err != nil {
+		log.Println("error al guardar el VGM:", err)
+	} else {
+		log.Println("VGM guardado en", liteboy.vgmPath)
+	}
+}
+
+// startMIDI transcribe las notas de los canales hasta stopMIDI
+func (liteboy *Liteboy) startMIDI(path string) {
+	liteboy.midiPath = path
+	liteboy.apu.StartMIDI()
+	log.Println("Grabando MIDI en", path)
+}
+
+func (liteboy *Liteboy) stopMIDI() {
+	midi := liteboy.apu.StopMIDI()
+	if midi == nil {
+		return
+	}
+	if err := midi.Save(liteboy.midiPath); err != nil {
+		log.Println("error al guardar el MIDI:", err)
+	} else {
+		log.Println("MIDI guardado en", liteboy.midiPath)
+	}
+}
+
+// Teclas de cada botón del joypad
+var gamepadKeys = []struct {
+	key    ebiten.Key
+	button byte
+}{
+	{ebiten.KeyRight, buttonRight},
+	{ebiten.KeyLeft, buttonLeft},
+	{ebiten.KeyUp, buttonUp},
+	{ebiten.KeyDown, buttonDown},
+	{ebiten.KeyZ, buttonA},
+	{ebiten.KeyX, buttonB},
+	{ebiten.KeySpace, buttonSelect},
+	{ebiten.KeyEnter, buttonStart},
+}
+
+func (liteboy *Liteboy) handleGamepad() {
+	var pressed byte
+	for _, k := range gamepadKeys {
+		if ebiten.IsKeyPressed(k.key) {
+			pressed |= k.button
+		}
+	}
+	liteboy.updateJoypad(pressed)
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/bus"
+	"github.com/deybismelendez/liteboy/cartridge"
+	"github.com/deybismelendez/liteboy/cpu"
+	"github.com/deybismelendez/liteboy/ppu"
+	"github.com/deybismelendez/liteboy/timer"
+	"github.com/deybismelendez/liteboy/video"
+)
+
+// T-ciclos de un frame completo (154 líneas de 456 ciclos)
+const CyclesPerFrame = 70224
+
+// machine agrupa los componentes de un Game Boy DMG conectados al mismo bus
+type machine struct {
+	cart  *cartridge.Cartridge
+	bus   *bus.Bus
+	ppu   *ppu.PPU
+	timer *timer.Timer
+	apu   *apu.APU
+	cpu   *cpu.CPU
+	// Postproceso de los frames (ghosting, escalador y grilla)
+	video *video.Pipeline
+	// Salida de audio de la APU y grabación de video en curso
+	audio     *recordingSink
+	recording *recording
+}
+
+func newMachine(cart *cartridge.Cartridge, sink apu.AudioSink) *machine {
+	gameBus := bus.NewBus(cart)
+	gamePPU := ppu.NewPPU(gameBus)
+	gameTimer := timer.NewTimer(gameBus)
+	audio := &recordingSink{output: sink}
+	gameAPU := apu.NewAPU(gameBus, audio)
+	gameCPU := cpu.NewCPU(gameBus, gameTimer, gamePPU, gameAPU)
+	return &machine{
+		cart:  cart,
+		bus:   gameBus,
+		ppu:   gamePPU,
+		timer: gameTimer,
+		apu:   gameAPU,
+		cpu:   gameCPU,
+		video: video.NewPipeline(),
+		audio: audio,
+	}
+}
+
+// runFrames emula la cantidad de frames indicada sin interfaz gráfica
+func (m *machine) runFrames(frames int) {
+	for range frames {
+		for !m.ppu.FrameReady() {
+			m.cpu.Step()
+		}
+		m.endFrame()
+	}
+}
+
+// endFrame se llama cuando el PPU termina un frame: al comenzar el VBlank o,
+// con el LCD apagado, cada CyclesPerFrame ciclos
+func (m *machine) endFrame() {
+	m.video.Push(m.ppu.Framebuffer)
+	m.recordFrame()
+}
@@ -1,46 +1,232 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/deybismelendez/liteboy/apu"
-	"github.com/deybismelendez/liteboy/bus"
 	"github.com/deybismelendez/liteboy/cartridge"
-	"github.com/deybismelendez/liteboy/cpu"
 	"github.com/deybismelendez/liteboy/ppu"
-	"github.com/deybismelendez/liteboy/timer"
+	"github.com/deybismelendez/liteboy/video"
+)
 
-	"github.com/hajimehoshi/ebiten/v2"
+const (
+	ScreenWidth  = 160
+	ScreenHeight = 144
+	Scale        = 4 // Escala por defecto de la ventana
 )
 
+// frontendOptions son las opciones de la línea de comandos que usan la
+// ventana (ver runWindow) y la terminal (ver runTerminal)
+type frontendOptions struct {
+	paletteSpec     string
+	palettes        ppu.Palettes
+	wavPath         string
+	wavStems        bool
+	vgmPath         string
+	midiPath        string
+	reportAccess    bool
+	ghosting        float64
+	grid            bool
+	filter          string
+	scale           int
+	integerScale    bool
+	screenshotScale int
+	recordPath      string
+	recordFormat    string
+	recordScale     int
+	fullscreen      bool
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Uso: go run main.go <path_a_la_rom.gb>")
+	if len(os.Args) > 1 && os.Args[1] == "gbs" {
+		runGBSCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "test" {
+		runTestCommand(os.Args[2:])
 		return
 	}
 
-	romPath := os.Args[1]
-	if len(os.Args) == 3 && os.Args[2] == "--info" {
-		cart := cartridge.NewCartridge(romPath)
+	flags := flag.NewFlagSet("liteboy", flag.ExitOnError)
+	info := flags.Bool("info", false, "muestra la información de la cabecera de la ROM")
+	headless := flags.Bool("headless", false, "emula sin ventana ni audio")
+	tui := flags.Bool("tui", false, "juega en la terminal (sin ventana ni audio), por ejemplo por SSH")
+	frames := flags.Int("frames", 600, "frames a emular en modo headless")
+	wavPath := flags.String("wav", "", "graba el audio en el archivo WAV indicado")
+	wavStems := flags.Bool("stems", false, "graba además un WAV mono por canal (chan1-chan4)")
+	vgmPath := flags.String("vgm", "", "graba las escrituras en los registros de sonido en el archivo VGM indicado")
+	midiPath := flags.String("midi", "", "transcribe las notas de los canales al archivo MIDI indicado")
+	vramDir := flags.String("dump-vram", "", "en modo headless, guarda al terminar los visores de VRAM (tiles, mapas y OAM) como PNG en la carpeta indicada")
+	reportAccess := flags.Bool("report-access", false, "informa los accesos de la CPU a VRAM/OAM bloqueados por el modo del PPU")
+	ghosting := flags.Float64("ghosting", 0, "simula la respuesta lenta del LCD mezclando cada frame con los anteriores (0 = apagado, 0.5 = la mitad)")
+	grid := flags.Bool("grid", false, "dibuja la grilla de puntos del LCD")
+	filter := flags.String("filter", "none", "escalador de pixel art: "+strings.Join(video.FilterNames(), ", "))
+	scale := flags.Int("scale", Scale, "escala inicial de la ventana")
+	integerScale := flags.Bool("integer-scale", false, "al ajustar la pantalla a la ventana solo usa escalas enteras")
+	screenshotPath := flags.String("screenshot", "", "en modo headless, guarda el último frame como PNG en el archivo (o carpeta) indicado")
+	screenshotScale := flags.Int("screenshot-scale", 1, "escala de las capturas: 1 = 160x144 sin escalador, más de 1 aplica --filter y --grid")
+	recordPath := flags.String("record", "", "graba el video de cada frame emulado: .avi (con audio), .y4m (con un .wav aparte), .png (PNG animado) o .gif")
+	recordFormat := flags.String("record-format", "avi", "formato de las grabaciones iniciadas con R: "+strings.Join(video.RecordFormats, ", "))
+	recordScale := flags.Int("record-scale", 1, "escala de las grabaciones de video, como --screenshot-scale")
+	fullscreen := flags.Bool("fullscreen", false, "inicia en pantalla completa")
+	paletteSpec := flags.String("palette", "auto", "paleta de colores: auto (la del boot ROM de CGB), grey, green, pocket, light, cgb-up ... cgb-right-b, un archivo de paleta o una lista de 4 o 12 colores RRGGBB")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: liteboy [opciones] <path_a_la_rom.gb>")
+		fmt.Fprintln(flags.Output(), "     liteboy gbs <archivo.gbs> [opciones]")
+		fmt.Fprintln(flags.Output(), "     liteboy test <carpeta> [opciones]")
+		flags.PrintDefaults()
+	}
+	args := parseArgs(flags, os.Args[1:])
+	if len(args) < 1 {
+		flags.Usage()
+		return
+	}
+
+	romPath := args[0]
+	cart := cartridge.NewCartridge(romPath)
+	if *info {
 		cart.PrintHeaderInfo()
 		os.Exit(0)
 	}
 
-	cart := cartridge.NewCartridge(romPath)
-	gameBus := bus.NewBus(cart)
-	gamePPU := ppu.NewPPU(gameBus)
-	gameTimer := timer.NewTimer(gameBus)
-	gameAPU := apu.NewAPU(gameBus)
-	gameCPU := cpu.NewCPU(gameBus, gameTimer, gamePPU, gameAPU)
-	game := NewLiteboy(gameCPU, gamePPU, gameBus)
-
-	// Configurar ventana y correr el loop de Ebiten
-	ebiten.SetWindowSize(ScreenWidth*Scale, ScreenHeight*Scale)
-	ebiten.SetWindowTitle("LiteBoy Emulator")
-	ebiten.SetTPS(60)
-	if err := ebiten.RunGame(game); err != nil {
+	palettes, err := loadPalettes(cart, *paletteSpec)
+	if err != nil {
 		log.Fatal(err)
 	}
+	if !slices.Contains(video.RecordFormats, *recordFormat) {
+		log.Fatalf("formato de video desconocido %q, se esperaba uno de: %s", *recordFormat, strings.Join(video.RecordFormats, ", "))
+	}
+	if _, ok := video.LookupFilter(*filter); !ok {
+		log.Fatalf("filtro desconocido %q, se esperaba uno de: %s", *filter, strings.Join(video.FilterNames(), ", "))
+	}
+
+	opts := frontendOptions{
+		paletteSpec:     *paletteSpec,
+		palettes:        palettes,
+		wavPath:         *wavPath,
+		wavStems:        *wavStems,
+		vgmPath:         *vgmPath,
+		midiPath:        *midiPath,
+		reportAccess:    *reportAccess,
+		ghosting:        *ghosting,
+		grid:            *grid,
+		filter:          *filter,
+		scale:           *scale,
+		integerScale:    *integerScale,
+		screenshotScale: *screenshotScale,
+		recordPath:      *recordPath,
+		recordFormat:    *recordFormat,
+		recordScale:     *recordScale,
+		fullscreen:      *fullscreen,
+	}
+	if *tui {
+		if err := runTerminal(cart, opts); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *headless {
+		var recorder *apu.WAVRecorder
+		var sink apu.AudioSink = apu.NullSink{}
+		if *wavPath != "" {
+			recorder, err = apu.NewWAVRecorder(*wavPath, *wavStems)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sink = recorder
+		}
+		m := newMachine(cart, sink)
+		m.ppu.SetPalettes(palettes)
+		m.video.Ghosting = *ghosting
+		m.video.Grid = *grid
+		m.video.Filter = *filter
+		m.video.Scale = *scale
+		m.bus.ReportBlockedAccess = *reportAccess
+		if *vgmPath != "" {
+			m.apu.StartVGM()
+		}
+		if *midiPath != "" {
+			m.apu.StartMIDI()
+		}
+		if *recordPath != "" {
+			if err := m.startRecording(*recordPath, *recordScale); err != nil {
+				log.Fatal(err)
+			}
+		}
+		m.runFrames(*frames)
+		m.stopRecording()
+		if *reportAccess {
+			log.Println("Accesos bloqueados por el PPU:", m.bus.BlockedAccesses)
+		}
+		if *screenshotPath != "" {
+			path, err := saveScreenshot(cart, m.video, *screenshotPath, *screenshotScale)
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Println("Captura guardada en", path)
+		}
+		if *vramDir != "" {
+			if err := saveVRAMImages(m.ppu, *vramDir); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if recorder != nil {
+			if err := recorder.Close(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if *vgmPath != "" {
+			if err := m.apu.StopVGM().Save(*vgmPath); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if *midiPath != "" {
+			if err := m.apu.StopMIDI().Save(*midiPath); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+
+	runWindow(cart, opts)
+}
+
+// parseArgs permite mezclar opciones y argumentos posicionales, por ejemplo
+// "liteboy rom.gb --info", y devuelve los argumentos posicionales
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// loadPalettes elige la paleta indicada por --palette: "auto" usa la que el
+// boot ROM de CGB asigna al juego, un archivo existente se lee como archivo
+// de paleta y cualquier otro texto es un nombre de paleta o lista de colores
+func loadPalettes(cart *cartridge.Cartridge, spec string) (ppu.Palettes, error) {
+	if spec == "auto" {
+		return autoPalettes(cart), nil
+	}
+	if info, err := os.Stat(spec); err == nil && !info.IsDir() {
+		return ppu.LoadPalettes(spec)
+	}
+	return ppu.ParsePalettes(spec)
+}
+
+func autoPalettes(cart *cartridge.Cartridge) ppu.Palettes {
+	title := append([]byte(cart.Title), cart.CGBFlag)
+	palettes, _ := ppu.CGBPalettes(title, cart.IsNintendoLicensed())
+	return palettes
 }
@@ -4,15 +4,9 @@ import (
 	"testing"
 
 	"github.com/deybismelendez/liteboy/apu"
-	"github.com/deybismelendez/liteboy/bus"
 	"github.com/deybismelendez/liteboy/cartridge"
-	"github.com/deybismelendez/liteboy/cpu"
-	"github.com/deybismelendez/liteboy/ppu"
-	"github.com/deybismelendez/liteboy/timer"
 )
 
-var passValues []byte = []byte{3, 5, 8, 13, 21, 34}
-var failValues []byte = []byte{0x42, 0x42, 0x42, 0x42, 0x42, 0x42}
 var mooneyeAcceptance = map[string]string{
 	"add_sp_e_timing":                 "roms/mooneye/acceptance/add_sp_e_timing.gb",
 	"bits/mem_oam":                    "roms/mooneye/acceptance/bits/mem_oam.gb",
@@ -40,13 +34,18 @@ var mooneyeAcceptance = map[string]string{
 	"oam_dma_start":                   "roms/mooneye/acceptance/oam_dma_start.gb",
 	"oam_dma_timing":                  "roms/mooneye/acceptance/oam_dma_timing.gb",
 	"pop_timing":                      "roms/mooneye/acceptance/pop_timing.gb",
+	"ppu/hblank_ly_scx_timing-GS":     "roms/mooneye/acceptance/ppu/hblank_ly_scx_timing-GS.gb",
+	"ppu/intr_1_2_timing-GS":          "roms/mooneye/acceptance/ppu/intr_1_2_timing-GS.gb",
 	"ppu/intr_2_0_timing":             "roms/mooneye/acceptance/ppu/intr_2_0_timing.gb",
 	"ppu/intr_2_mode0_timing_sprites": "roms/mooneye/acceptance/ppu/intr_2_mode0_timing_sprites.gb",
 	"ppu/intr_2_mode0_timing":         "roms/mooneye/acceptance/ppu/intr_2_mode0_timing.gb",
 	"ppu/intr_2_mode3_timing":         "roms/mooneye/acceptance/ppu/intr_2_mode3_timing.gb",
 	"ppu/intr_2_oam_ok_timing":        "roms/mooneye/acceptance/ppu/intr_2_oam_ok_timing.gb",
+	"ppu/lcdon_timing-GS":             "roms/mooneye/acceptance/ppu/lcdon_timing-GS.gb",
+	"ppu/lcdon_write_timing-GS":       "roms/mooneye/acceptance/ppu/lcdon_write_timing-GS.gb",
 	"ppu/stat_irq_blocking":           "roms/mooneye/acceptance/ppu/stat_irq_blocking.gb",
 	"ppu/stat_lyc_onoff":              "roms/mooneye/acceptance/ppu/stat_lyc_onoff.gb",
+	"ppu/vblank_stat_intr-GS":         "roms/mooneye/acceptance/ppu/vblank_stat_intr-GS.gb",
 	"push_timing":                     "roms/mooneye/acceptance/push_timing.gb",
 	"rapid_di_ei":                     "roms/mooneye/acceptance/rapid_di_ei.gb",
 	"reti_intr_timing":                "roms/mooneye/acceptance/reti_intr_timing.gb",
@@ -82,21 +81,17 @@ func TestMooneyeAcceptance(t *testing.T) {
 // Verifica registros después de muchos ciclos buscando los valores esperados
 func runMooneyeTestROM(path string) bool {
 	cart := cartridge.NewCartridge(path)
-	gameBus := bus.NewBus(cart)
-	gamePPU := ppu.NewPPU(gameBus)
-	gameTimer := timer.NewTimer(gameBus)
-	gameAPU := apu.NewAPU(gameBus)
-	gameCPU := cpu.NewCPU(gameBus, gameTimer, gamePPU, gameAPU)
+	m := newMachine(cart, apu.NullSink{})
 
-	for range 1_000_000 {
-		opcode := gameCPU.GetOpcode()
-		//c := gameCPU.Step()
+	for range 4_000_000 {
+		opcode := m.cpu.GetOpcode()
+		//c := m.cpu.Step()
 		//gamePPU.Step(c)
-		gameCPU.Step()
+		m.cpu.Step()
 
 		// Si ejecuta LD B, B (0x40), revisamos los registros
 		if opcode == 0x40 {
-			regs := gameCPU.GetRegisters()
+			regs := m.cpu.GetRegisters()
 
 			if equalBytes(regs, passValues) {
 				return true
@@ -107,15 +102,3 @@ func runMooneyeTestROM(path string) bool {
 	}
 	return false // Timeout o sin detectar resultado
 }
-
-func equalBytes(a, b []byte) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
-	}
-	return true
-}
@@ -0,0 +1,23 @@
+//go:build tui
+
+package main
+
+import (
+	"log"
+
+	"github.com/deybismelendez/liteboy/cartridge"
+)
+
+// Compilado con -tags tui el emulador no usa Ebitengine, así que no necesita
+// cgo ni las bibliotecas de X11 y ALSA: sin --headless la ROM se juega en la
+// terminal.
+
+func runWindow(cart *cartridge.Cartridge, opts frontendOptions) {
+	if err := runTerminal(cart, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runGBSCommand(args []string) {
+	log.Fatal("el reproductor GBS necesita Ebitengine: compilá liteboy sin -tags tui")
+}
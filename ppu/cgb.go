@@ -0,0 +1,224 @@
+package ppu
+
+// Paletas de compatibilidad del boot ROM de CGB. Al arrancar un juego DMG
+// con licencia de Nintendo el boot ROM suma los 16 bytes del título
+// (0x0134-0x0143) y busca el resultado en cgbChecksums; las últimas 14 sumas
+// se repiten entre juegos y se desambiguan con la cuarta letra del título.
+// El índice encontrado elige en cgbPaletteIDs una combinación de
+// cgbCombinations, que a su vez indica qué colores de cgbColors usan OBP0,
+// OBP1 y el fondo. Las tablas son las del boot ROM, en el mismo orden.
+
+// Sumas de los títulos. Las primeras cgbUniqueChecksums no se repiten; la
+// primera (0x00) es la de la paleta por defecto.
+var cgbChecksums = [...]byte{
+	0x00, 0x88, 0x16, 0x36, 0xD1, 0xDB, 0xF2, 0x3C, 0x8C, 0x92, 0x3D, 0x5C, 0x58, 0xC9, 0x3E, 0x70,
+	0x1D, 0x59, 0x69, 0x19, 0x35, 0xA8, 0x14, 0xAA, 0x75, 0x95, 0x99, 0x34, 0x6F, 0x15, 0xFF, 0x97,
+	0x4B, 0x90, 0x17, 0x10, 0x39, 0xF7, 0xF6, 0xA2, 0x49, 0x4E, 0x43, 0x68, 0xE0, 0x8B, 0xF0, 0xCE,
+	0x0C, 0x29, 0xE8, 0xB7, 0x86, 0x9A, 0x52, 0x01, 0x9D, 0x71, 0x9C, 0xBD, 0x5D, 0x6D, 0x67, 0x3F,
+	0x6B,
+	// Sumas repetidas: se decide con cgbFourthLetters
+	0xB3, 0x46, 0x28, 0xA5, 0xC6, 0xD3, 0x27, 0x61, 0x18, 0x66, 0x6A, 0xBF, 0x0D, 0xF4,
+}
+
+const (
+	cgbUniqueChecksums = 65
+	cgbRepeatedSums    = len(cgbChecksums) - cgbUniqueChecksums
+)
+
+// Cuarta letra de cada juego con suma repetida. La letra i corresponde a la
+// suma cgbChecksums[cgbUniqueChecksums+i%14] y al índice cgbUniqueChecksums+i
+// de cgbPaletteIDs; por ejemplo "POKEMON BLUE" (0x61, 'E') y "VEGAS STAKES"
+// (0x61, 'A').
+const cgbFourthLetters = "BEFAARBEKEK R-URAR INAILICE R"
+
+// Combinación de cgbCombinations de cada juego, en el orden de las sumas
+// y luego de las cuartas letras
+var cgbPaletteIDs = [...]byte{
+	0, 4, 5, 35, 34, 3, 31, 15, 10, 5, 19, 36, 7, 37, 30, 44,
+	21, 32, 31, 20, 5, 33, 13, 14, 5, 29, 5, 18, 9, 3, 2, 26,
+	25, 25, 41, 42, 26, 45, 42, 45, 36, 38, 26, 42, 30, 41, 34, 34,
+	5, 42, 6, 5, 33, 25, 42, 42, 40, 2, 16, 25, 42, 42, 5, 0,
+	39,
+	36, 22, 25, 6, 32, 12, 36, 11, 39, 18, 39, 24, 31, 50,
+	17, 46, 6, 27, 0, 47, 41, 41, 0, 0, 19, 34, 23, 18, 29,
+}
+
+// Colores de las paletas en el formato de la CGB (BGR de 5 bits), de a 4
+var cgbColors = [...]uint16{
+	0x7FFF, 0x32BF, 0x00D0, 0x0000, // 0 marrón
+	0x639F, 0x4279, 0x15B0, 0x04CB,
+	0x7FFF, 0x6E31, 0x454A, 0x0000,
+	0x7FFF, 0x1BEF, 0x0200, 0x0000, // 3 verde
+	0x7FFF, 0x421F, 0x1CF2, 0x0000, // 4 rojo
+	0x7FFF, 0x5294, 0x294A, 0x0000, // 5 gris
+	0x7FFF, 0x03FF, 0x012F, 0x0000,
+	0x7FFF, 0x03EF, 0x01D6, 0x0000,
+	0x7FFF, 0x42B5, 0x3DC8, 0x0000,
+	0x7E74, 0x03FF, 0x0180, 0x0000,
+	0x67FF, 0x77AC, 0x1A13, 0x2D6B,
+	0x7ED6, 0x4BFF, 0x2175, 0x0000,
+	0x53FF, 0x4A5F, 0x7E52, 0x0000,
+	0x4FFF, 0x7ED2, 0x3A4C, 0x1CE0,
+	0x03ED, 0x7FFF, 0x255F, 0x0000,
+	0x036A, 0x021F, 0x03FF, 0x7FFF,
+	0x7FFF, 0x01DF, 0x0112, 0x0000,
+	0x231F, 0x035F, 0x00F2, 0x0009,
+	0x7FFF, 0x03EA, 0x011F, 0x0000,
+	0x299F, 0x001A, 0x000C, 0x0000,
+	0x7FFF, 0x027F, 0x001F, 0x0000,
+	0x7FFF, 0x03E0, 0x0206, 0x0120,
+	0x7FFF, 0x7EEB, 0x001F, 0x7C00,
+	0x7FFF, 0x3FFF, 0x7E00, 0x001F,
+	0x7FFF, 0x03FF, 0x001F, 0x0000,
+	0x03FF, 0x001F, 0x000C, 0x0000,
+	0x7FFF, 0x033F, 0x0193, 0x0000,
+	0x0000, 0x4200, 0x037F, 0x7FFF,
+	0x7FFF, 0x7E8C, 0x7C00, 0x0000, // 28 azul
+	0x7FFF, 0x1BEF, 0x6180, 0x0000, // 29 verde y azul (por defecto)
+}
+
+// cgbCombination indica desde qué color de cgbColors empiezan las paletas
+// de OBP0, OBP1 y el fondo. Casi siempre son múltiplos de 4, pero el boot
+// ROM tiene tres combinaciones (22, 34 y 35) que empiezan a mitad de una
+// paleta y mezclan colores de dos.
+type cgbCombination struct {
+	obp0, obp1, bg int
+}
+
+// pal crea una combinación a partir de números de paleta
+func pal(obp0, obp1, bg int) cgbCombination {
+	return cgbCombination{obp0 * 4, obp1 * 4, bg * 4}
+}
+
+var cgbCombinations = [...]cgbCombination{
+	pal(4, 4, 29),   // 0: Derecha + A, y la de los juegos que no están
+	pal(18, 18, 18), // 1: Derecha
+	pal(20, 20, 20),
+	pal(24, 24, 24), // 3: Abajo + A
+	pal(9, 9, 9),
+	pal(0, 0, 0),    // 5: Arriba
+	pal(27, 27, 27), // 6: Derecha + B
+	pal(5, 5, 5),    // 7: Izquierda + B
+	pal(12, 12, 12), // 8: Abajo
+	pal(26, 26, 26),
+	pal(16, 8, 8), // 10
+	pal(4, 28, 28),
+	pal(4, 2, 2),
+	pal(3, 4, 4),
+	pal(4, 29, 29),
+	pal(28, 4, 28),
+	pal(2, 17, 2),
+	pal(16, 16, 8),
+	pal(4, 4, 7),
+	pal(4, 4, 18),
+	pal(4, 4, 20), // 20
+	pal(19, 19, 9),
+	{4*4 - 1, 4*4 - 1, 11 * 4},
+	pal(17, 17, 2),
+	pal(4, 4, 2),
+	pal(4, 4, 3),
+	pal(28, 28, 0),
+	pal(3, 3, 0),
+	pal(0, 0, 1), // 28: Arriba + B
+	pal(18, 22, 18),
+	pal(20, 22, 20), // 30
+	pal(24, 22, 24),
+	pal(16, 22, 8),
+	pal(17, 4, 13),
+	{28*4 - 1, 0 * 4, 14 * 4},
+	{28*4 - 1, 4 * 4, 15 * 4},
+	pal(19, 22, 9),
+	pal(16, 28, 10),
+	pal(4, 23, 28),
+	pal(17, 22, 2),
+	pal(4, 0, 2), // 40: Izquierda + A
+	pal(4, 28, 3),
+	pal(28, 3, 0),
+	pal(3, 28, 4), // 43: Arriba + A
+	pal(21, 28, 4),
+	pal(3, 28, 0),
+	pal(25, 3, 28),
+	pal(0, 28, 8),
+	pal(4, 3, 28),  // 48: Izquierda
+	pal(28, 3, 6),  // 49: Abajo + B
+	pal(4, 28, 29), // 50
+}
+
+// Combinaciones que el boot ROM deja elegir con la cruceta y A/B durante
+// el logo, con el nombre de la paleta incluida que las usa
+var cgbManualCombinations = map[string]int{
+	"cgb-up": 5, "cgb-up-a": 43, "cgb-up-b": 28,
+	"cgb-left": 48, "cgb-left-a": 40, "cgb-left-b": 7,
+	"cgb-down": 8, "cgb-down-a": 3, "cgb-down-b": 49,
+	"cgb-right": 1, "cgb-right-a": 0, "cgb-right-b": 6,
+}
+
+// cgbPalette convierte los 4 colores de cgbColors que empiezan en start
+func cgbPalette(start int) Palette {
+	var palette Palette
+	for i := range palette {
+		c := cgbColors[start+i]
+		palette[i] = Pixel{R: cgbChannel(c), G: cgbChannel(c >> 5), B: cgbChannel(c >> 10), A: 0xFF}
+	}
+	return palette
+}
+
+// cgbChannel lleva un canal de 5 bits a 8 bits redondeando
+func cgbChannel(c uint16) byte {
+	return byte((int(c&0x1F)*255 + 15) / 31)
+}
+
+// cgbPalettes devuelve las paletas de una combinación de cgbCombinations
+func cgbPalettes(id byte) Palettes {
+	c := cgbCombinations[id]
+	return Palettes{BG: cgbPalette(c.bg), OBP0: cgbPalette(c.obp0), OBP1: cgbPalette(c.obp1)}
+}
+
+// Paleta de los juegos que no están en la tabla
+var cgbDefault = cgbPalettes(0)
+
+// TitleChecksum suma los bytes del título como el boot ROM de CGB
+func TitleChecksum(title []byte) byte {
+	var sum byte
+	for _, b := range title {
+		sum += b
+	}
+	return sum
+}
+
+// CGBPalettes devuelve las paletas que el boot ROM de CGB asigna a un juego
+// DMG. title son los 16 bytes de 0x0134-0x0143 y nintendo indica si la
+// licencia (antigua 0x01, o nueva "01" con la antigua en 0x33) es de Nintendo.
+// El segundo valor es false si se usó la paleta por defecto.
+func CGBPalettes(title []byte, nintendo bool) (Palettes, bool) {
+	id, ok := cgbPaletteID(title, nintendo)
+	return cgbPalettes(id), ok
+}
+
+// cgbPaletteID busca la combinación de un título como el boot ROM
+func cgbPaletteID(title []byte, nintendo bool) (byte, bool) {
+	if !nintendo {
+		return 0, false
+	}
+	checksum := TitleChecksum(title)
+	for i, sum := range cgbChecksums {
+		if sum != checksum {
+			continue
+		}
+		if i < cgbUniqueChecksums {
+			return cgbPaletteIDs[i], true
+		}
+		// Suma repetida: la cuarta letra elige entre los juegos con esa suma
+		var letter byte
+		if len(title) > 3 {
+			letter = title[3]
+		}
+		for j := i - cgbUniqueChecksums; j < len(cgbFourthLetters); j += cgbRepeatedSums {
+			if cgbFourthLetters[j] == letter {
+				return cgbPaletteIDs[cgbUniqueChecksums+j], true
+			}
+		}
+		break
+	}
+	return 0, false
+}
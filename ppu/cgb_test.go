@@ -0,0 +1,138 @@
+package ppu
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// title rellena el título hasta los 16 bytes de la cabecera
+func title(name string) []byte {
+	b := make([]byte, 16)
+	copy(b, name)
+	return b
+}
+
+func TestTitleChecksum(t *testing.T) {
+	cases := map[string]byte{
+		"POKEMON RED":     0x14,
+		"POKEMON BLUE":    0x61,
+		"TETRIS":          0xDB,
+		"SUPER MARIOLAND": 0x46,
+	}
+	for name, want := range cases {
+		if got := TitleChecksum(title(name)); got != want {
+			t.Errorf("%s: suma %02X, se esperaba %02X", name, got, want)
+		}
+	}
+}
+
+func TestCGBPalettes(t *testing.T) {
+	cases := []struct {
+		title string
+		id    byte
+	}{
+		{"POKEMON RED", 13},
+		{"POKEMON GREEN", 14}, // Suma 0xAA
+		{"TETRIS", 3},
+		{"ZELDA", 44},
+		// Misma suma 0x61, distinta cuarta letra
+		{"POKEMON BLUE", 11},
+		{"VEGAS STAKES", 41},
+		// Misma suma 0xBF
+		{"KID ICARUS", 24},
+		{"SOCCER", 34},
+	}
+	for _, c := range cases {
+		palettes, found := CGBPalettes(title(c.title), true)
+		if !found || palettes != cgbPalettes(c.id) {
+			t.Errorf("%s: encontrado %v, se esperaba la combinación %d", c.title, found, c.id)
+		}
+	}
+
+	// Misma suma que SUPER MARIOLAND pero una cuarta letra que no está en la tabla
+	other := title("SUPER MARIOLAND")
+	other[3], other[4] = 'X', 'R'-('X'-'E')
+	if TitleChecksum(other) != 0x46 {
+		t.Fatal("la suma debería seguir siendo 0x46")
+	}
+	if _, found := CGBPalettes(other, true); found {
+		t.Fatal("la cuarta letra debería desambiguar la suma")
+	}
+
+	// Sin licencia de Nintendo no se busca en la tabla
+	palettes, found := CGBPalettes(title("TETRIS"), false)
+	if found || palettes != cgbDefault {
+		t.Fatalf("licencia ajena: %v", found)
+	}
+	if palettes, _ := CGBPalettes(title("HOMEBREW GAME"), true); palettes != cgbDefault {
+		t.Fatal("un juego desconocido debería usar la paleta por defecto")
+	}
+}
+
+func TestCGBTables(t *testing.T) {
+	if len(cgbChecksums) != 79 || len(cgbPaletteIDs) != 94 || len(cgbFourthLetters) != 29 {
+		t.Fatalf("tamaños %d/%d/%d, el boot ROM tiene 79 sumas, 94 combinaciones y 29 letras",
+			len(cgbChecksums), len(cgbPaletteIDs), len(cgbFourthLetters))
+	}
+	for i, id := range cgbPaletteIDs {
+		if int(id) >= len(cgbCombinations) {
+			t.Errorf("juego %d: combinación %d fuera de la tabla", i, id)
+		}
+	}
+	for i, c := range cgbCombinations {
+		if max(c.obp0, c.obp1, c.bg)+4 > len(cgbColors) {
+			t.Errorf("combinación %d fuera de los colores", i)
+		}
+	}
+
+	// Colores de las paletas que se eligen a mano, según Pan Docs
+	cases := map[string]Palette{
+		"cgb-up-b":    rgb(0xFFE6C5, 0xCE9C84, 0x846B29, 0x5A3108),
+		"cgb-left-a":  rgb(0xFFFFFF, 0x8C8CDE, 0x52528C, 0x000000),
+		"cgb-left-b":  rgb(0xFFFFFF, 0xA5A5A5, 0x525252, 0x000000),
+		"cgb-down":    rgb(0xFFFFA5, 0xFF9494, 0x9494FF, 0x000000),
+		"cgb-down-b":  rgb(0xFFFFFF, 0xFFFF00, 0x7B4A00, 0x000000),
+		"cgb-right":   rgb(0xFFFFFF, 0x52FF00, 0xFF4200, 0x000000),
+		"cgb-right-a": rgb(0xFFFFFF, 0x7BFF31, 0x0063C5, 0x000000),
+		"cgb-right-b": rgb(0x000000, 0x008484, 0xFFDE00, 0xFFFFFF),
+	}
+	for name, want := range cases {
+		if got := builtinPalettes[name].BG; got != want {
+			t.Errorf("%s: fondo %v, se esperaba %v", name, got, want)
+		}
+	}
+	upA := builtinPalettes["cgb-up-a"]
+	if upA.BG != rgb(0xFFFFFF, 0xFF8484, 0x943A3A, 0x000000) || upA.OBP0 != rgb(0xFFFFFF, 0x7BFF31, 0x008400, 0x000000) || upA.OBP1 != rgb(0xFFFFFF, 0x63A5FF, 0x0000FF, 0x000000) {
+		t.Errorf("cgb-up-a: %v", upA)
+	}
+}
+
+// Si hay un volcado del boot ROM de CGB en roms/cgb_boot.bin se comparan las
+// tablas con las suyas: las sumas, seguidas de las combinaciones de cada juego
+// (el bit 7 es una marca que no se usa para elegir la paleta) y las letras
+func TestCGBTablesMatchBootROM(t *testing.T) {
+	dump, err := os.ReadFile("../roms/cgb_boot.bin")
+	if err != nil {
+		t.Skip("no hay volcado del boot ROM de CGB en roms/cgb_boot.bin")
+	}
+	start := bytes.Index(dump, cgbChecksums[1:9])
+	if start < 1 {
+		t.Fatal("no se encontró la tabla de sumas en el volcado")
+	}
+	start--
+	tables := dump[start:]
+	if !bytes.Equal(tables[:len(cgbChecksums)], cgbChecksums[:]) {
+		t.Errorf("sumas:\n% X\nvolcado:\n% X", cgbChecksums, tables[:len(cgbChecksums)])
+	}
+	ids := tables[len(cgbChecksums) : len(cgbChecksums)+len(cgbPaletteIDs)]
+	for i, id := range ids {
+		if id&0x7F != cgbPaletteIDs[i] {
+			t.Errorf("juego %d: combinación %d, el volcado tiene %d", i, cgbPaletteIDs[i], id&0x7F)
+		}
+	}
+	letters := tables[len(cgbChecksums)+len(cgbPaletteIDs):]
+	if string(letters[:len(cgbFourthLetters)]) != cgbFourthLetters {
+		t.Errorf("letras %q, el volcado tiene %q", cgbFourthLetters, letters[:len(cgbFourthLetters)])
+	}
+}
@@ -0,0 +1,61 @@
+package ppu
+
+import "image"
+
+// SetLayerVisible oculta o muestra una capa al dibujar sin modificar LCDC,
+// así que el juego no nota el cambio y los timings no varían. Una capa de
+// fondo o window oculta se dibuja con el color 0 y los sprites ocultos
+// dejan ver lo que hay debajo.
+func (ppu *PPU) SetLayerVisible(layer Layer, visible bool) {
+	ppu.hiddenLayers[layer] = !visible
+}
+
+func (ppu *PPU) LayerVisible(layer Layer) bool {
+	return !ppu.hiddenLayers[layer]
+}
+
+// SpriteBox es el rectángulo en pantalla de un sprite de la OAM
+type SpriteBox struct {
+	Index int
+	Layer Layer // LayerOBJ0 o LayerOBJ1
+	Rect  image.Rectangle
+}
+
+// SpriteBoxes devuelve los rectángulos en pantalla de los sprites de la OAM
+// que quedan al menos en parte dentro de la pantalla. Puede extenderse fuera
+// de los 160x144 píxeles.
+func (ppu *PPU) SpriteBoxes() []SpriteBox {
+	height := ppu.getObjHeight()
+	var boxes []SpriteBox
+	for _, entry := range ppu.OAMEntries() {
+		if !entry.Visible(height) {
+			continue
+		}
+		layer := LayerOBJ0
+		if entry.OBP1() {
+			layer = LayerOBJ1
+		}
+		x := int(entry.X) - 8
+		y := int(entry.Y) - 16
+		boxes = append(boxes, SpriteBox{
+			Index: entry.Index,
+			Layer: layer,
+			Rect:  image.Rect(x, y, x+8, y+int(height)),
+		})
+	}
+	return boxes
+}
+
+// WindowRect devuelve la zona de la pantalla cubierta por la window según
+// WX/WY, o false si la window está apagada o fuera de la pantalla
+func (ppu *PPU) WindowRect() (image.Rectangle, bool) {
+	if !ppu.isWindowEnabled() {
+		return image.Rectangle{}, false
+	}
+	wx := int(ppu.bus.Read(WXRegister)) - 7
+	wy := int(ppu.bus.Read(WYRegister))
+	if wx >= ScreenWidth || wy >= ScreenHeight {
+		return image.Rectangle{}, false
+	}
+	return image.Rect(max(wx, 0), wy, ScreenWidth, ScreenHeight), true
+}
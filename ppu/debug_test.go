@@ -0,0 +1,73 @@
+package ppu
+
+import (
+	"image"
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func TestHiddenLayers(t *testing.T) {
+	setup := func(b *bus.Bus) {
+		for row := range 8 {
+			b.VRAM[16+row*2] = 0xFF   // Tile 1: color 1
+			b.VRAM[32+row*2+1] = 0xFF // Tile 2: color 2
+		}
+		for i := range 32 * 32 {
+			b.VRAM[0x1800+i] = 1
+			b.VRAM[0x1C00+i] = 2
+		}
+		b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay|LCDCFlagWindowEnable|LCDCFlagWindowTileMap)
+		b.Write(WXRegister, 7+80)
+		writeOAM(b, 0, 16, 8+8, 2, 0x80) // Detrás del fondo
+		writeOAM(b, 1, 16, 8+100, 1, 0)
+	}
+
+	p, b := newTestPPU()
+	setup(b)
+	p.SetLayerVisible(LayerBG, false)
+	runToLine(t, p, b, 5)
+	lcdc := b.Read(LCDCRegister)
+	if p.ShadeAt(40, 4) != 0 || p.ShadeAt(90, 4) != 2 || p.LayerAt(100, 4) != LayerOBJ0 {
+		t.Fatal("solo el fondo debería ocultarse")
+	}
+	if p.ShadeAt(10, 4) != 0 || p.LayerAt(10, 4) != LayerBG {
+		t.Fatal("ocultar el fondo no debería cambiar la prioridad del sprite de detrás")
+	}
+	if b.Read(LCDCRegister) != lcdc || lcdc&LCDCFlagBGEnablePriority == 0 {
+		t.Fatal("ocultar una capa no debe modificar LCDC")
+	}
+
+	p, b = newTestPPU()
+	setup(b)
+	p.SetLayerVisible(LayerWindow, false)
+	p.SetLayerVisible(LayerOBJ0, false)
+	runToLine(t, p, b, 5)
+	if p.ShadeAt(40, 4) != 1 || p.ShadeAt(100, 4) != 0 || p.LayerAt(110, 4) != LayerWindow {
+		t.Fatal("la window y los sprites deberían ocultarse")
+	}
+}
+
+func TestSpriteBoxesAndWindowRect(t *testing.T) {
+	p, b := newTestPPU()
+	writeOAM(b, 3, 16+10, 8+20, 0, 0x10)
+	writeOAM(b, 7, 0, 50, 0, 0) // Fuera de la pantalla
+	boxes := p.SpriteBoxes()
+	if len(boxes) != 1 || boxes[0].Index != 3 || boxes[0].Layer != LayerOBJ1 || boxes[0].Rect != image.Rect(20, 10, 28, 18) {
+		t.Fatalf("rectángulos = %v", boxes)
+	}
+
+	if _, ok := p.WindowRect(); ok {
+		t.Fatal("la window está apagada")
+	}
+	b.Write(LCDCRegister, 0x91|LCDCFlagWindowEnable)
+	b.Write(WXRegister, 7+60)
+	b.Write(WYRegister, 100)
+	if rect, ok := p.WindowRect(); !ok || rect != image.Rect(60, 100, 160, 144) {
+		t.Fatalf("window = %v %v", rect, ok)
+	}
+	b.Write(WYRegister, 200)
+	if _, ok := p.WindowRect(); ok {
+		t.Fatal("la window está debajo de la pantalla")
+	}
+}
@@ -0,0 +1,127 @@
+package ppu
+
+// Pasos del fetcher de fondo/window: cada lectura toma 2 dots y luego
+// intenta empujar los 8 píxeles en cada dot hasta que la FIFO esté vacía
+const (
+	fetchTileDots = 2
+	fetchLowDots  = 4
+	fetchHighDots = 6
+)
+
+// fetcher lee tiles del fondo o de la window para la FIFO de fondo
+type fetcher struct {
+	ticks     int  // Dots transcurridos desde el último push
+	tileX     byte // Columna del siguiente tile (relativa a SCX o a la window)
+	window    bool
+	tileIndex byte
+	low       byte
+	high      byte
+}
+
+func (f *fetcher) reset(window bool) {
+	*f = fetcher{window: window}
+}
+
+// stepFetcher avanza un dot el fetcher de fondo/window
+func (ppu *PPU) stepFetcher() {
+	f := &ppu.fetcher
+	if f.ticks < fetchHighDots {
+		f.ticks++
+		switch f.ticks {
+		case fetchTileDots:
+			f.tileIndex = ppu.bus.Read(ppu.fetcherTileMapAddr())
+		case fetchLowDots:
+			f.low = ppu.bus.Read(ppu.fetcherTileDataAddr())
+		case fetchHighDots:
+			f.high = ppu.bus.Read(ppu.fetcherTileDataAddr() + 1)
+		}
+		return
+	}
+
+	// Push: solo cuando la FIFO de fondo quedó vacía
+	if ppu.bgFIFO.size > 0 {
+		return
+	}
+	for bit := 7; bit >= 0; bit-- {
+		ppu.bgFIFO.push(fifoPixel{color: (((f.high >> bit) & 1) << 1) | ((f.low >> bit) & 1)})
+	}
+	f.tileX++
+	f.ticks = 0
+}
+
+// fetcherRow devuelve la fila (0-255) del mapa de tiles que se está dibujando
+func (ppu *PPU) fetcherRow() uint16 {
+	if ppu.fetcher.window {
+		return ppu.windowLineCounter
+	}
+	return (uint16(ppu.ly) + uint16(ppu.bus.Read(SCYRegister))) & 0xFF
+}
+
+func (ppu *PPU) fetcherTileMapAddr() uint16 {
+	f := &ppu.fetcher
+	var mapAddr uint16
+	var column uint16
+	if f.window {
+		mapAddr = ppu.getWindowTileMapArea()
+		column = uint16(f.tileX) & 0x1F
+	} else {
+		mapAddr = ppu.getBGTileMapArea()
+		column = (uint16(ppu.bus.Read(SCXRegister)/8) + uint16(f.tileX)) & 0x1F
+	}
+	return mapAddr + (ppu.fetcherRow()/8)*32 + column
+}
+
+func (ppu *PPU) fetcherTileDataAddr() uint16 {
+	var tileAddr uint16
+	if ppu.getBGAndWindowTileDataArea() == 0x8000 {
+		tileAddr = 0x8000 + uint16(ppu.fetcher.tileIndex)*16
+	} else {
+		tileAddr = uint16(0x9000 + int(int8(ppu.fetcher.tileIndex))*16)
+	}
+	return tileAddr + (ppu.fetcherRow()%8)*2
+}
+
+// fetchSprite lee la fila actual del sprite y la mezcla en la FIFO de
+// sprites. Los píxeles ya ocupados por sprites anteriores (menor X o menor
+// índice OAM) tienen prioridad.
+func (ppu *PPU) fetchSprite(sprite *Sprite) {
+	spriteHeight := ppu.getObjHeight()
+	line := int(ppu.ly) - (int(sprite.Y) - 16)
+	if sprite.Atributes&0x40 != 0 { // Y flip
+		line = int(spriteHeight) - 1 - line
+	}
+	tileIndex := sprite.TileIndex
+	if spriteHeight == 16 {
+		tileIndex &= 0xFE // Ignorar bit 0 en modo 8x16
+	}
+	tileAddr := 0x8000 + uint16(tileIndex)*16 + uint16(line)*2
+	low := ppu.bus.Read(tileAddr)
+	high := ppu.bus.Read(tileAddr + 1)
+
+	var palette byte
+	if sprite.Atributes&0x10 != 0 {
+		palette = 1
+	}
+	for x := range 8 {
+		screenX := int(sprite.X) - 8 + x
+		if screenX < ppu.lx {
+			continue // Fuera de la pantalla por la izquierda
+		}
+		bit := 7 - x
+		if sprite.Atributes&0x20 != 0 { // X flip
+			bit = x
+		}
+		pixel := fifoPixel{
+			color:    (((high >> bit) & 1) << 1) | ((low >> bit) & 1),
+			palette:  palette,
+			priority: sprite.Atributes&0x80 != 0,
+		}
+		slot := screenX - ppu.lx
+		for ppu.objFIFO.size <= slot {
+			ppu.objFIFO.push(fifoPixel{})
+		}
+		if ppu.objFIFO.at(slot).color == 0 {
+			*ppu.objFIFO.at(slot) = pixel
+		}
+	}
+}
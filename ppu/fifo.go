@@ -0,0 +1,38 @@
+package ppu
+
+// fifoPixel es un píxel pendiente en la FIFO de fondo o de sprites. El color
+// es el índice dentro del tile; la paleta se aplica al sacarlo de la FIFO.
+type fifoPixel struct {
+	color    byte
+	palette  byte // Sprites: 0 = OBP0, 1 = OBP1
+	priority bool // Sprites: atributo BG over OBJ
+}
+
+// pixelFIFO es una cola circular de hasta 8 píxeles
+type pixelFIFO struct {
+	pixels [8]fifoPixel
+	head   int
+	size   int
+}
+
+func (f *pixelFIFO) push(p fifoPixel) {
+	f.pixels[(f.head+f.size)%len(f.pixels)] = p
+	f.size++
+}
+
+func (f *pixelFIFO) pop() fifoPixel {
+	p := f.pixels[f.head]
+	f.head = (f.head + 1) % len(f.pixels)
+	f.size--
+	return p
+}
+
+// at devuelve el i-ésimo píxel desde la cabeza para poder mezclar sprites
+func (f *pixelFIFO) at(i int) *fifoPixel {
+	return &f.pixels[(f.head+i)%len(f.pixels)]
+}
+
+func (f *pixelFIFO) clear() {
+	f.head = 0
+	f.size = 0
+}
@@ -0,0 +1,36 @@
+package ppu
+
+// Layer indica de qué capa salió un píxel de la pantalla
+type Layer byte
+
+const (
+	LayerBG Layer = iota
+	LayerWindow
+	LayerOBJ0 // Sprite con la paleta OBP0
+	LayerOBJ1 // Sprite con la paleta OBP1
+)
+
+func (l Layer) String() string {
+	switch l {
+	case LayerBG:
+		return "BG"
+	case LayerWindow:
+		return "Window"
+	case LayerOBJ0:
+		return "OBJ0"
+	case LayerOBJ1:
+		return "OBJ1"
+	}
+	return "?"
+}
+
+// ShadeAt devuelve el tono (0-3, después de BGP/OBP0/OBP1) del píxel de la
+// pantalla, sin depender de la paleta RGB
+func (ppu *PPU) ShadeAt(x, y int) byte {
+	return ppu.Shades[y*ScreenWidth+x]
+}
+
+// LayerAt devuelve la capa que dibujó el píxel de la pantalla
+func (ppu *PPU) LayerAt(x, y int) Layer {
+	return ppu.Layers[y*ScreenWidth+x]
+}
@@ -0,0 +1,205 @@
+package ppu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func fillBackground(b *bus.Bus) {
+	for i := range 16 {
+		b.VRAM[16+i] = 0xFF // Tile 1 con color 3
+	}
+	for i := range 32 * 32 {
+		b.VRAM[0x1800+i] = 1
+	}
+}
+
+func TestLCDOffClearsScreen(t *testing.T) {
+	p, b := newTestPPU()
+	fillBackground(b)
+	runToLine(t, p, b, 20)
+	if p.ShadeAt(0, 10) != 3 {
+		t.Fatal("el fondo debería estar dibujado")
+	}
+
+	b.Write(LCDCRegister, 0x11)
+	p.Step(4)
+	if b.Read(LYRegister) != 0 || p.getMode() != ModeHBlank {
+		t.Fatalf("LCD apagado: LY=%d modo=%d", b.Read(LYRegister), p.getMode())
+	}
+	if p.ShadeAt(0, 10) != 0 || p.Framebuffer[getFramebufferIndex(0, 10)] != p.palettes.BG[0].R {
+		t.Fatal("con el LCD apagado la pantalla debería quedar en blanco")
+	}
+	p.Step(456 * 10)
+	if b.Read(LYRegister) != 0 {
+		t.Fatal("LY no debería avanzar con el LCD apagado")
+	}
+}
+
+func TestLCDOffDuringBlankFrame(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LCDCRegister, 0x11)
+	p.Step(4)
+	b.Write(LCDCRegister, 0x91)
+	p.Step(4)
+	// Restos de otra imagen mientras el primer frame no se muestra
+	p.Shades[10*ScreenWidth] = 3
+	p.Framebuffer[getFramebufferIndex(0, 10)] = 0
+
+	b.Write(LCDCRegister, 0x11)
+	p.Step(4)
+	if p.ShadeAt(0, 10) != 0 || p.Framebuffer[getFramebufferIndex(0, 10)] != p.palettes.BG[0].R {
+		t.Fatal("apagar el LCD durante el primer frame debería dejar la pantalla en blanco")
+	}
+}
+
+func TestLCDOnFirstLineAndBlankFrame(t *testing.T) {
+	p, b := newTestPPU()
+	fillBackground(b)
+	b.Write(LCDCRegister, 0x11)
+	p.Step(4)
+	b.Write(STATRegister, 0x20) // Interrupción de modo 2
+	b.Write(0xFF0F, 0)
+	b.Write(LCDCRegister, 0x91)
+
+	// Primera línea: modo 0 en lugar de modo 2, sin interrupción STAT
+	dots := 0
+	for p.getMode() == ModeHBlank {
+		p.Step(1)
+		dots++
+	}
+	if dots != 80-lcdOnPhaseDots || p.getMode() != ModeVRAM {
+		t.Fatalf("modo 0 inicial de %d dots, luego modo %d", dots, p.getMode())
+	}
+	if b.Read(0xFF0F)&(1<<InterruptSTAT) != 0 {
+		t.Fatal("la primera línea no tiene modo 2")
+	}
+	for b.Read(LYRegister) == 0 {
+		p.Step(1)
+		dots++
+	}
+	if dots != 456-lyEarlyDots-lcdOnPhaseDots {
+		t.Fatalf("la primera línea duró %d dots", dots)
+	}
+
+	// El primer frame no se muestra; el siguiente sí
+	runToLine(t, p, b, 20)
+	if p.ShadeAt(0, 10) != 0 {
+		t.Fatal("el primer frame tras encender el LCD debería quedar en blanco")
+	}
+	runToLine(t, p, b, 0)
+	runToLine(t, p, b, 20)
+	if p.ShadeAt(0, 10) != 3 {
+		t.Fatal("el segundo frame debería dibujarse")
+	}
+}
+
+func TestLYChangesBeforeMode2(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LYCRegister, 11)
+	runToLine(t, p, b, 10)
+	dots := 0
+	for b.Read(LYRegister) == 10 {
+		p.Step(1)
+		dots++
+	}
+	if dots != 456-lyEarlyDots || p.getMode() != ModeHBlank {
+		t.Fatalf("LY cambió a los %d dots, en modo %d", dots, p.getMode())
+	}
+	if b.Read(STATRegister)&0x04 != 0 {
+		t.Fatal("LY no debería coincidir con LYC hasta el modo 2")
+	}
+	// La OAM ya no se puede leer, pero sí escribir
+	b.Client = bus.ClientCPU
+	b.OAM[0] = 0x12
+	b.Write(0xFE01, 0x34)
+	if b.Read(0xFE00) != 0xFF || b.OAM[1] != 0x34 {
+		t.Fatal("con LY adelantado la OAM se bloquea solo para leer")
+	}
+
+	p.Step(lyEarlyDots)
+	if p.getMode() != ModeOAM || b.Read(STATRegister)&0x04 == 0 {
+		t.Fatal("el modo 2 debería empezar con LY=LYC")
+	}
+	// Al final del modo 2 la OAM se puede escribir y la VRAM ya no se puede leer
+	p.Step(80 - oamScanDoneDots - 1)
+	b.Client = bus.ClientCPU
+	b.Write(0xFE02, 0x56)
+	if b.OAM[2] == 0x56 || b.Read(0x8000) == 0xFF {
+		t.Fatal("antes del final del modo 2 la OAM no se puede escribir")
+	}
+	p.Step(1)
+	b.Client = bus.ClientCPU
+	b.Write(0xFE02, 0x56)
+	if b.OAM[2] != 0x56 || b.Read(0x8000) != 0xFF || b.Read(0xFE00) != 0xFF {
+		t.Fatal("al final del modo 2 la OAM se escribe y la VRAM no se lee")
+	}
+}
+
+func TestLine153WrapsEarly(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LYCRegister, 0)
+	runToLine(t, p, b, 1)
+	for p.ly != 153 {
+		p.Step(1)
+	}
+	b.Write(STATRegister, b.Read(STATRegister)&^0x04)
+	if b.Read(LYRegister) != 153 {
+		t.Fatalf("LY = %d al comenzar la línea 153", b.Read(LYRegister))
+	}
+	p.Step(lyWrapDots)
+	if b.Read(LYRegister) != 0 || b.Read(STATRegister)&0x04 == 0 {
+		t.Fatal("LY debería valer 0 y coincidir con LYC=0 durante la línea 153")
+	}
+	if p.getMode() != ModeVBlank {
+		t.Fatal("la línea 153 sigue siendo VBlank")
+	}
+
+	// Un frame completo dura 154 líneas
+	runToLine(t, p, b, 1)
+	frame := 0
+	for {
+		p.Step(1)
+		frame++
+		if p.getMode() == ModeOAM && p.ly == 1 && p.cycles == 0 {
+			break
+		}
+	}
+	if frame != 154*456 {
+		t.Fatalf("el frame duró %d dots", frame)
+	}
+}
+
+func TestFrameReady(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LCDCRegister, 0x91)
+	runToLine(t, p, b, 0)
+	p.FrameReady()
+
+	// Con el LCD encendido el frame termina al comenzar la línea 144
+	dots := 0
+	for !p.FrameReady() {
+		p.Step(1)
+		dots++
+	}
+	if b.Read(LYRegister) != ScreenHeight || dots != ScreenHeight*456 {
+		t.Fatalf("frame listo en LY=%d después de %d dots", b.Read(LYRegister), dots)
+	}
+	if p.FrameReady() {
+		t.Fatal("FrameReady debería avisar una sola vez por frame")
+	}
+
+	// Apagado, los frames siguen llegando cada 70224 dots
+	b.Write(LCDCRegister, 0x11)
+	for range 3 {
+		dots = 0
+		for !p.FrameReady() {
+			p.Step(4)
+			dots += 4
+		}
+		if dots != frameDots {
+			t.Fatalf("con el LCD apagado el frame duró %d dots", dots)
+		}
+	}
+}
@@ -1,19 +1,35 @@
 package ppu
 
+// Dots antes del final de la línea en que LY ya vale la línea siguiente.
+// Mientras tanto STAT sigue en modo 0 y LY no coincide con LYC, pero la OAM
+// ya está ocupada para la CPU.
+const lyEarlyDots = 4
+
 func (ppu *PPU) runHBlank() {
-	if ppu.cycles < 204 {
+	// En la primera línea tras encender el LCD no hay búsqueda en la OAM:
+	// STAT indica modo 0 hasta que empieza el modo 3
+	if ppu.firstLine && ppu.cycles == 80 {
+		ppu.firstLine = false
+		ppu.spritesOnCurrentLine = nil
+		ppu.checkWindowY()
+		ppu.startVRAM()
+		ppu.setMode(ModeVRAM)
 		return
 	}
-	ppu.cycles -= 204
-	ly := ppu.bus.Read(LYRegister)
-	if ly == 144 {
+	if ppu.cycles == 456-lyEarlyDots {
+		ppu.bus.LYChanging = true
+		ppu.bus.Write(LYRegister, ppu.ly+1)
+	}
+	if ppu.cycles < 456 {
+		return
+	}
+	ppu.cycles -= 456
+	ppu.bus.LYChanging = false
+	ppu.ly++
+	if ppu.ly == ScreenHeight {
+		ppu.blankFrame = false
 		ppu.setMode(ModeVBlank)
-		//ppu.requestInterrupt(InterruptVBlank)
-		//ppu.requestInterrupt(InterruptSTAT)
 	} else {
-		ppu.bus.Write(LYRegister, ly+1)
-		ppu.updateCoincidenceFlag()
 		ppu.setMode(ModeOAM)
 	}
-
 }
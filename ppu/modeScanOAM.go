@@ -1,15 +1,22 @@
 package ppu
 
+// Dots del final del modo 2 en los que el PPU ya terminó con la OAM: la CPU
+// puede escribirla pero no leer la VRAM
+const oamScanDoneDots = 4
+
 func (ppu *PPU) scanOAM() {
+	if ppu.cycles == 80-oamScanDoneDots {
+		ppu.bus.OAMScanDone = true
+	}
 	if ppu.cycles < 80 {
 		return
 	}
-	ppu.cycles -= 80
+	ppu.bus.OAMScanDone = false
 
 	spriteHeight := ppu.getObjHeight()
 
 	var result []*Sprite
-	ly := ppu.bus.Read(LYRegister)
+	ly := ppu.ly
 
 	for i := uint16(0); i < 40; i++ {
 		var index uint16 = i * 4
@@ -20,7 +27,7 @@ func (ppu *PPU) scanOAM() {
 
 		// Posición real de y es y - 16
 		if ly >= y-16 && ly < (y-16)+spriteHeight {
-			sprite := newSprite(x, y, tile, attr)
+			sprite := newSprite(x, y, tile, attr, i)
 			result = append(result, sprite)
 
 			if len(result) == MaxSpritesPerLine {
@@ -29,5 +36,6 @@ func (ppu *PPU) scanOAM() {
 		}
 	}
 	ppu.spritesOnCurrentLine = result
+	ppu.startVRAM()
 	ppu.setMode(ModeVRAM)
 }
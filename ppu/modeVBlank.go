@@ -1,20 +1,24 @@
 package ppu
 
+// Dots de la línea 153 en los que LY todavía vale 153 antes de pasar a 0
+const lyWrapDots = 4
+
 func (ppu *PPU) runVBlank() {
-	if ppu.isVBlankInterruptEnabled() {
-		ppu.requestInterrupt(InterruptSTAT)
+	// En la línea 153 LY pasa a 0 antes de tiempo y se compara con LYC
+	if ppu.ly == 153 && ppu.cycles == lyWrapDots {
+		ppu.bus.Write(LYRegister, 0)
 	}
 	if ppu.cycles < 456 {
 		return
 	}
 	ppu.cycles -= 456
-	ly := ppu.bus.Read(LYRegister)
-	if ly == 154 {
-		ppu.bus.Write(LYRegister, 0)
-		ppu.updateCoincidenceFlag()
+	if ppu.ly == 153 {
+		// LY ya vale 0 desde el comienzo de la línea 153
+		ppu.ly = 0
+		ppu.resetWindow()
 		ppu.setMode(ModeOAM)
-	} else {
-		ppu.bus.Write(LYRegister, ly+1)
-		ppu.updateCoincidenceFlag()
+		return
 	}
+	ppu.ly++
+	ppu.bus.Write(LYRegister, ppu.ly)
 }
@@ -1,197 +1,153 @@
 package ppu
 
-import (
-	"sort"
-)
+// Duración del primer fetch de cada línea, que el hardware descarta
+const firstFetchDots = 6
+
+// Dots que tarda en leerse un sprite una vez que el fetcher de fondo terminó
+const spriteFetchDots = 6
+
+// startVRAM prepara el fetcher y las FIFO al comenzar el modo 3
+func (ppu *PPU) startVRAM() {
+	ppu.bgFIFO.clear()
+	ppu.objFIFO.clear()
+	ppu.fetcher.reset(false)
+	ppu.lx = 0
+	ppu.discard = int(ppu.bus.Read(SCXRegister) & 0x07)
+	ppu.vramDelay = firstFetchDots
+	ppu.objFetch = nil
+	ppu.objTile = -1
+	ppu.windowActive = false
+	ppu.windowDrawn = false
+	ppu.startLineWindow()
+}
 
+// runVRAM avanza un dot del modo 3. La duración del modo varía con el
+// desplazamiento fino de SCX, la window y los sprites de la línea.
 func (ppu *PPU) runVRAM() {
-	// Calculamos el número de ciclos basados en la posición de SCX y los sprites
-	ppu.pixelFIFO = ppu.pixelFIFO[:0]
-	if ppu.cycles < 172 {
+	if ppu.vramDelay > 0 {
+		ppu.vramDelay--
 		return
 	}
-	ppu.cycles -= 172
 
-	// Si ya se dibujaron todas las líneas pasa a HBlank
-	ly := ppu.bus.Read(LYRegister)
-	if ly >= ScreenHeight {
-		ppu.setMode(ModeHBlank)
+	// Un sprite pendiente detiene la salida de píxeles: primero se espera a
+	// que el fetcher de fondo termine su tile y luego se lee el sprite
+	if ppu.objFetch != nil {
+		ppu.stepSpriteFetch()
 		return
 	}
 
-	// Procedemos con el renderizado de una línea...
-	scx := ppu.bus.Read(SCXRegister)
-	scy := ppu.bus.Read(SCYRegister)
-	wx := ppu.bus.Read(WXRegister)
-	wy := ppu.bus.Read(WYRegister)
-	isWindowEnabled := ppu.isWindowEnabled()
+	ppu.updateWindow()
+	ppu.stepFetcher()
 
-	bgTileMapAddr := ppu.getBGTileMapArea()
-	bgWindowTileData := ppu.getBGAndWindowTileDataArea()
-
-	useSigned := true
-	if bgWindowTileData == 0x8000 {
-		useSigned = false
-	}
-	isWindowLine := isWindowEnabled && int(ly) >= int(wy) && int(wx) < ScreenWidth
-	if ly == wy && isWindowEnabled {
-		ppu.windowLineCounter = 0
+	if ppu.bgFIFO.size == 0 {
+		return
 	}
-	for x := range ScreenWidth {
-		if !ppu.isBGEnabled() {
-			ppu.addPixelToFIFO(getColorFromPalette(0))
-			continue
-		}
-		var scrollX, scrollY uint16
-		var tileMapAddr uint16
-		if isWindowLine && x >= int(wx)-7 {
-			// Dibujamos Window
-			tileMapAddr = ppu.getWindowTileMapArea()
-
-			windowY := ppu.windowLineCounter
-			windowX := uint16(x) - (uint16(wx) - 7)
-
-			tileX := windowX / 8
-			tileY := windowY / 8
-			tileIndexOffset := tileY*32 + tileX
-			tileIndex := ppu.bus.Read(tileMapAddr + tileIndexOffset)
-
-			var tileAddr uint16
-			if useSigned {
-				signedTileIndex := int8(tileIndex)
-				if signedTileIndex >= 0 {
-					tileAddr = 0x9000 + uint16(signedTileIndex)*16
-				} else {
-					tileAddr = 0x9000 - uint16(signedTileIndex*-1)*16
-				}
-			} else {
-				tileAddr = 0x8000 + uint16(tileIndex)*16
-			}
-
-			row := (windowY % 8) * 2
-			byte1 := ppu.bus.Read(tileAddr + uint16(row))
-			byte2 := ppu.bus.Read(tileAddr + uint16(row) + 1)
-			bit := 7 - (windowX % 8)
-
-			colorID := (((byte2 >> bit) & 1) << 1) | ((byte1 >> bit) & 1)
-			palette := ppu.bus.Read(0xFF47)
-			color := (palette >> (colorID * 2)) & 0x03
-			ppu.addPixelToFIFO(getColorFromPalette(color))
-
-		} else { // if ppu.isBGEnabled() { // bit 0 lcdc
-			// Dibujamos Background
-			scrollX = (uint16(x) + uint16(scx)) & 0xFF
-			scrollY = (uint16(ly) + uint16(scy)) & 0xFF
-
-			tileX := (scrollX) / 8
-			tileY := (scrollY) / 8
-
-			tileIndexOffset := tileY*32 + tileX
-			tileIndex := ppu.bus.Read(bgTileMapAddr + tileIndexOffset)
-
-			var tileAddr uint16
-			if useSigned {
-				tileAddr = 0x9000 + uint16(int8(tileIndex))*16
-			} else {
-				tileAddr = 0x8000 + uint16(tileIndex)*16
-			}
-
-			row := (scrollY % 8) * 2
-			byte1 := ppu.bus.Read(tileAddr + uint16(row))
-			byte2 := ppu.bus.Read(tileAddr + uint16(row) + 1)
-			bit := 7 - (scrollX % 8)
-
-			colorID := (((byte2 >> bit) & 1) << 1) | ((byte1 >> bit) & 1)
-			palette := ppu.bus.Read(0xFF47)
-			color := (palette >> (colorID * 2)) & 0x03
-			ppu.addPixelToFIFO(getColorFromPalette(color))
+
+	if ppu.discard == 0 && ppu.isObjEnabled() {
+		if sprite := ppu.nextSprite(); sprite != nil {
+			ppu.objFetch = sprite
+			ppu.objWait = ppu.spriteWait(sprite)
+			ppu.objDots = spriteFetchDots
+			ppu.stepSpriteFetch()
+			return
 		}
 	}
-	for x := range ScreenWidth {
-		ppu.popPixelFromFIFO(x, int(ly))
+
+	ppu.shiftPixel()
+	if ppu.lx == ScreenWidth {
+		ppu.endLineWindow()
+		ppu.setMode(ModeHBlank)
 	}
-	if ppu.isObjEnabled() {
-		ppu.renderSprites()
+}
+
+func (ppu *PPU) stepSpriteFetch() {
+	if ppu.objWait > 0 {
+		ppu.objWait--
+		ppu.stepFetcher()
+		return
 	}
-	if isWindowLine {
-		ppu.windowLineCounter++
+	ppu.objDots--
+	if ppu.objDots == 0 {
+		ppu.fetchSprite(ppu.objFetch)
+		ppu.objFetch.fetched = true
+		ppu.objFetch = nil
 	}
-	ppu.setMode(ModeHBlank)
 }
 
-func (ppu *PPU) renderSprites() {
-	// Prioridad de sprites en coordenadas X
-	sort.SliceStable(ppu.spritesOnCurrentLine, func(i, j int) bool {
-		si, sj := ppu.spritesOnCurrentLine[i], ppu.spritesOnCurrentLine[j]
-		if si.X == sj.X {
-			return i > j // Prioridad por orden en OAM
-		}
-		return si.X > sj.X // Prioridad por X
-	})
-	spriteHeight := ppu.getObjHeight()
+// spriteWait devuelve los dots que el sprite espera al fetcher de fondo: los
+// que le faltan al tile que está debajo de su primer píxel, salvo que otro
+// sprite ya haya esperado por ese mismo tile. Los sprites con X < 8 caen en el
+// tile descartado al comienzo de la línea.
+func (ppu *PPU) spriteWait(sprite *Sprite) int {
+	shift := int(ppu.bus.Read(SCXRegister) & 0x07)
+	if ppu.windowActive {
+		shift = int(255-ppu.bus.Read(WXRegister)) & 0x07
+	}
+	x := int(sprite.X) + shift
+	if x/8 == ppu.objTile {
+		return 0
+	}
+	ppu.objTile = x / 8
+	return max(0, 5-x%8)
+}
 
-	ly := ppu.bus.Read(LYRegister)
+// nextSprite devuelve el sprite que empieza en la columna actual; si hay
+// varios, el de menor X y luego el de menor índice OAM
+func (ppu *PPU) nextSprite() *Sprite {
+	var next *Sprite
 	for _, sprite := range ppu.spritesOnCurrentLine {
-		spriteY := int(sprite.Y) - 16
-		spriteX := int(sprite.X) - 8
-		line := int(ly) - spriteY
-
-		if sprite.Atributes&0x40 != 0 { // Y flip
-			line = int(spriteHeight) - 1 - line
+		if sprite.fetched || int(sprite.X)-8 > ppu.lx {
+			continue
 		}
-
-		tileIndex := sprite.TileIndex
-		if spriteHeight == 16 {
-			tileIndex &= 0xFE // Ignorar bit 0 en modo 8x16
+		if next == nil || spriteHasPriority(sprite, next) {
+			next = sprite
 		}
+	}
+	return next
+}
 
-		tileAddr := 0x8000 + uint16(tileIndex)*16 + uint16(line)*2
-		byte1 := ppu.bus.Read(tileAddr)
-		byte2 := ppu.bus.Read(tileAddr + 1)
-
-		for x := range 8 {
-			bit := 7 - x
-			if sprite.Atributes&0x20 != 0 { // X flip
-				bit = x
-			}
-
-			colorID := (((byte2 >> bit) & 1) << 1) | ((byte1 >> bit) & 1)
-			if colorID == 0 {
-				continue // Transparente
-			}
-
-			var paletteAddr uint16 = 0xFF48
-			if sprite.Atributes&0x10 != 0 {
-				paletteAddr = 0xFF49
-			}
-
-			palette := ppu.bus.Read(paletteAddr)
-			color := (palette >> (colorID * 2)) & 0x03
-			screenX := spriteX + x
-
-			if (screenX < 0) || (screenX >= ScreenWidth) {
-				continue
-			}
-
-			// Prioridad: fondo (bit 7)
-			bgPriority := sprite.Atributes&0x80 != 0
-			pixelIndex := getFramebufferIndex(screenX, int(ly))
-			if bgPriority {
-				// Omitimos dibujar si fondo no es color 0
-				bgPixel := newPixel(ppu.Framebuffer[pixelIndex],
-					ppu.Framebuffer[pixelIndex+1],
-					ppu.Framebuffer[pixelIndex+2],
-					ppu.Framebuffer[pixelIndex+3])
-				if (bgPixel.R != WhiteColor.R) && (bgPixel.G != WhiteColor.G) && (bgPixel.B != WhiteColor.B) {
-					continue
-				}
-			}
-			pixel := getColorFromPalette(color)
-			ppu.Framebuffer[pixelIndex] = pixel.R
-			ppu.Framebuffer[pixelIndex+1] = pixel.G
-			ppu.Framebuffer[pixelIndex+2] = pixel.B
-			ppu.Framebuffer[pixelIndex+3] = pixel.A
+// shiftPixel saca un píxel de las FIFO, lo mezcla y lo escribe en el framebuffer
+func (ppu *PPU) shiftPixel() {
+	bg := ppu.bgFIFO.pop()
+	if ppu.discard > 0 {
+		ppu.discard--
+		return
+	}
+
+	var obj fifoPixel
+	if ppu.objFIFO.size > 0 {
+		obj = ppu.objFIFO.pop()
+	}
+
+	layer := LayerBG
+	if ppu.windowActive {
+		layer = LayerWindow
+	}
+	// En DMG el bit 0 de LCDC apaga fondo y window
+	if !ppu.isBGEnabled() {
+		bg.color = 0
+	}
+	// La prioridad se decide con el índice de color, no con el color de BGP
+	ppu.bgLine[ppu.lx] = bg.color
+	// Una capa oculta desde el depurador se dibuja con el color 0, pero los
+	// sprites detrás del fondo siguen ocultos como en el juego
+	if ppu.hiddenLayers[layer] {
+		bg.color = 0
+	}
+	shade := (ppu.bus.Read(BGPRegister) >> (bg.color * 2)) & 0x03
+	rgb := &ppu.palettes.BG
+	if ppu.isObjEnabled() && ppu.objWins(obj, ppu.lx) && !ppu.hiddenLayers[LayerOBJ0+Layer(obj.palette)] {
+		if obj.palette == 1 {
+			layer = LayerOBJ1
+			shade = (ppu.bus.Read(OBP1Register) >> (obj.color * 2)) & 0x03
+			rgb = &ppu.palettes.OBP1
+		} else {
+			layer = LayerOBJ0
+			shade = (ppu.bus.Read(OBP0Register) >> (obj.color * 2)) & 0x03
+			rgb = &ppu.palettes.OBP0
 		}
 	}
 
+	ppu.setPixel(ppu.lx, int(ppu.ly), shade, layer, rgb[shade])
+	ppu.lx++
 }
@@ -0,0 +1,108 @@
+package ppu
+
+// Bug de corrupción de la OAM del DMG. Mientras el PPU recorre la OAM en el
+// modo 2 (una fila de 8 bytes por ciclo de máquina), si la CPU pone en el bus
+// una dirección de 0xFE00-0xFEFF, ya sea leyendo, escribiendo o al
+// incrementar o decrementar un registro de 16 bits, la fila que el PPU está
+// leyendo se mezcla con la anterior.
+// https://gbdev.io/pandocs/OAM_Corruption_Bug.html
+
+type OAMBugKind byte
+
+const (
+	OAMBugWrite OAMBugKind = iota
+	OAMBugRead
+	// Lectura en el mismo ciclo que un incremento o decremento (POP, LD A,[HL+],
+	// LD A,[HL-]). Aplica la corrupción previa y después la de lectura normal,
+	// así que la CPU lee sin pasar por OnOAMAccess.
+	OAMBugReadIncrease
+)
+
+const oamRows = 20
+
+// TriggerOAMBug corrompe la OAM si addr está en 0xFE00-0xFEFF y el PPU
+// está leyendo la OAM. La lectura empieza cuando cambia LY, unos dots antes
+// de que STAT indique el modo 2 (ver lyEarlyDots), y las filas se cuentan
+// desde ahí.
+func (ppu *PPU) TriggerOAMBug(addr uint16, kind OAMBugKind) {
+	if addr < 0xFE00 || addr > 0xFEFF || !ppu.lcdOn || ppu.getMode() != ModeOAM {
+		return
+	}
+	dot := ppu.cycles + lyEarlyDots
+	if dot >= 80 {
+		return
+	}
+	row := dot / 4
+	switch kind {
+	case OAMBugWrite:
+		ppu.oamBugWrite(row)
+	case OAMBugRead:
+		ppu.oamBugRead(row)
+	case OAMBugReadIncrease:
+		ppu.oamBugReadIncrease(row)
+	}
+}
+
+// onOAMAccess recibe del bus las lecturas y escrituras de la CPU en 0xFE00-0xFEFF
+func (ppu *PPU) onOAMAccess(addr uint16, write bool) {
+	if write {
+		ppu.TriggerOAMBug(addr, OAMBugWrite)
+	} else {
+		ppu.TriggerOAMBug(addr, OAMBugRead)
+	}
+}
+
+func (ppu *PPU) oamWord(row, word int) uint16 {
+	i := row*8 + word*2
+	return uint16(ppu.bus.OAM[i]) | uint16(ppu.bus.OAM[i+1])<<8
+}
+
+func (ppu *PPU) setOAMWord(row, word int, value uint16) {
+	i := row*8 + word*2
+	ppu.bus.OAM[i] = byte(value)
+	ppu.bus.OAM[i+1] = byte(value >> 8)
+}
+
+// copyOAMRow copia los bytes desde from (0-7) de una fila a otra
+func (ppu *PPU) copyOAMRow(src, dst, from int) {
+	copy(ppu.bus.OAM[dst*8+from:dst*8+8], ppu.bus.OAM[src*8+from:src*8+8])
+}
+
+func (ppu *PPU) oamBugWrite(row int) {
+	if row == 0 {
+		return
+	}
+	a := ppu.oamWord(row, 0)
+	b := ppu.oamWord(row-1, 0)
+	c := ppu.oamWord(row-1, 2)
+	ppu.setOAMWord(row, 0, ((a^c)&(b^c))^c)
+	ppu.copyOAMRow(row-1, row, 2)
+}
+
+func (ppu *PPU) oamBugRead(row int) {
+	if row == 0 {
+		return
+	}
+	a := ppu.oamWord(row, 0)
+	b := ppu.oamWord(row-1, 0)
+	c := ppu.oamWord(row-1, 2)
+	ppu.setOAMWord(row, 0, b|(a&c))
+	ppu.copyOAMRow(row-1, row, 2)
+}
+
+// oamBugReadIncrease corrompe la fila anterior y la copia a la fila actual
+// y a la de dos filas antes, salvo en las cuatro primeras filas y en la
+// última. Después se aplica la corrupción de lectura normal con los valores
+// nuevos.
+func (ppu *PPU) oamBugReadIncrease(row int) {
+	if row >= 4 && row != oamRows-1 {
+		a := ppu.oamWord(row-2, 0)
+		b := ppu.oamWord(row-1, 0)
+		c := ppu.oamWord(row, 0)
+		d := ppu.oamWord(row-1, 2)
+		ppu.setOAMWord(row-1, 0, (b&(a|c|d))|(a&c&d))
+		ppu.copyOAMRow(row-1, row, 0)
+		ppu.copyOAMRow(row-1, row-2, 0)
+	}
+	ppu.oamBugRead(row)
+}
@@ -0,0 +1,121 @@
+package ppu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+// fillOAM llena cada palabra de la OAM con un valor distinto (fila<<8 | palabra)
+func fillOAM(b *bus.Bus) {
+	for row := range oamRows {
+		for word := range 4 {
+			i := row*8 + word*2
+			b.OAM[i] = byte(word)
+			b.OAM[i+1] = byte(row)
+		}
+	}
+}
+
+// runToOAMRow avanza hasta que el modo 2 de la línea 10 lee la fila indicada
+// (desde la 1: la 0 se lee antes de que STAT indique el modo 2)
+func runToOAMRow(t *testing.T, p *PPU, b *bus.Bus, row int) {
+	t.Helper()
+	runToLine(t, p, b, 10)
+	for (p.cycles+lyEarlyDots)/4 != row {
+		p.Step(1)
+	}
+}
+
+func TestOAMBugWrite(t *testing.T) {
+	p, b := newTestPPU()
+	fillOAM(b)
+	runToOAMRow(t, p, b, 5)
+	b.Client = bus.ClientCPU
+	b.Write(0xFE00, 0)
+
+	a, prev, c := uint16(0x0500), uint16(0x0400), uint16(0x0402)
+	if got, want := p.oamWord(5, 0), ((a^c)&(prev^c))^c; got != want {
+		t.Fatalf("palabra 0 de la fila 5 = %04X, se esperaba %04X", got, want)
+	}
+	for word := 1; word < 4; word++ {
+		if got := p.oamWord(5, word); got != p.oamWord(4, word) {
+			t.Fatalf("palabra %d de la fila 5 = %04X, se esperaba la de la fila 4", word, got)
+		}
+	}
+	if got := p.oamWord(6, 0); got != 0x0600 {
+		t.Fatalf("la fila 6 no debería cambiar, palabra 0 = %04X", got)
+	}
+}
+
+func TestOAMBugRead(t *testing.T) {
+	p, b := newTestPPU()
+	fillOAM(b)
+	runToOAMRow(t, p, b, 7)
+	b.Client = bus.ClientCPU
+	b.Read(0xFEFF)
+
+	if got, want := p.oamWord(7, 0), uint16(0x0600|(0x0700&0x0602)); got != want {
+		t.Fatalf("palabra 0 de la fila 7 = %04X, se esperaba %04X", got, want)
+	}
+	if got := p.oamWord(7, 3); got != 0x0603 {
+		t.Fatalf("palabra 3 de la fila 7 = %04X, se esperaba 0603", got)
+	}
+}
+
+func TestOAMBugReadIncrease(t *testing.T) {
+	p, b := newTestPPU()
+	fillOAM(b)
+	runToOAMRow(t, p, b, 6)
+	p.TriggerOAMBug(0xFE10, OAMBugReadIncrease)
+
+	a, prev, c, d := uint16(0x0400), uint16(0x0500), uint16(0x0600), uint16(0x0502)
+	want := (prev & (a | c | d)) | (a & c & d)
+	for _, row := range []int{4, 5, 6} {
+		if got := p.oamWord(row, 0); got != want {
+			t.Fatalf("palabra 0 de la fila %d = %04X, se esperaba %04X", row, got, want)
+		}
+		if got := p.oamWord(row, 2); got != 0x0502 {
+			t.Fatalf("palabra 2 de la fila %d = %04X, se esperaba 0502", row, got)
+		}
+	}
+}
+
+func TestOAMBugOnlyInMode2(t *testing.T) {
+	cases := []struct {
+		name string
+		addr uint16
+		run  func(t *testing.T, p *PPU, b *bus.Bus)
+	}{
+		{"modo 3", 0xFE00, func(t *testing.T, p *PPU, b *bus.Bus) {
+			runToLine(t, p, b, 10)
+			for p.getMode() != ModeVRAM {
+				p.Step(1)
+			}
+		}},
+		{"fila 0", 0xFE00, func(t *testing.T, p *PPU, b *bus.Bus) {
+			runToLine(t, p, b, 9)
+			for !b.LYChanging {
+				p.Step(1)
+			}
+		}},
+		{"fuera de la OAM", 0xFF80, func(t *testing.T, p *PPU, b *bus.Bus) { runToOAMRow(t, p, b, 5) }},
+		{"LCD apagado", 0xFE00, func(t *testing.T, p *PPU, b *bus.Bus) {
+			b.Write(LCDCRegister, 0)
+			p.Step(4)
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, b := newTestPPU()
+			fillOAM(b)
+			c.run(t, p, b)
+			before := b.OAM
+			p.TriggerOAMBug(c.addr, OAMBugWrite)
+			p.TriggerOAMBug(c.addr, OAMBugRead)
+			if b.OAM != before {
+				t.Fatal("la OAM no debería cambiar")
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package ppu
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Palette asigna un color RGB a cada uno de los 4 tonos del DMG (0 = más claro)
+type Palette [4]Pixel
+
+// Palettes son las paletas RGB usadas para el fondo/window y para los
+// sprites de OBP0 y OBP1. Se aplican después de BGP/OBP0/OBP1.
+type Palettes struct {
+	BG   Palette
+	OBP0 Palette
+	OBP1 Palette
+}
+
+// Nombres de las paletas incluidas, en el orden en que se recorren. Las
+// "cgb-*" son las que el boot ROM de CGB deja elegir con la cruceta y A/B.
+var PaletteNames = []string{
+	"grey", "green", "pocket", "light",
+	"cgb-up", "cgb-up-a", "cgb-up-b",
+	"cgb-left", "cgb-left-a", "cgb-left-b",
+	"cgb-down", "cgb-down-a", "cgb-down-b",
+	"cgb-right", "cgb-right-a", "cgb-right-b",
+}
+
+var builtinPalettes = map[string]Palettes{
+	// Grises usados originalmente por el emulador
+	"grey": uniform(rgb(0xEEEEEE, 0xAAAAAA, 0x555555, 0x000000)),
+	// Pantalla verde del DMG original
+	"green": uniform(rgb(0x9BBC0F, 0x8BAC0F, 0x306230, 0x0F380F)),
+	// Game Boy Pocket
+	"pocket": uniform(rgb(0xC4CFA1, 0x8B956D, 0x4D533C, 0x1F1F1F)),
+	// Game Boy Light con la luz de fondo encendida
+	"light": uniform(rgb(0x00B581, 0x009A71, 0x00694A, 0x004F3B)),
+}
+
+// Las paletas cgb-* son las combinaciones del boot ROM de CGB que se eligen
+// con la cruceta
+func init() {
+	for name, id := range cgbManualCombinations {
+		builtinPalettes[name] = cgbPalettes(byte(id))
+	}
+}
+
+// rgb crea una paleta a partir de 4 colores 0xRRGGBB
+func rgb(c0, c1, c2, c3 uint32) Palette {
+	var palette Palette
+	for i, c := range []uint32{c0, c1, c2, c3} {
+		palette[i] = rgbPixel(c)
+	}
+	return palette
+}
+
+// rgbPixel convierte un color 0xRRGGBB
+func rgbPixel(c uint32) Pixel {
+	return Pixel{R: byte(c >> 16), G: byte(c >> 8), B: byte(c), A: 0xFF}
+}
+
+func uniform(palette Palette) Palettes {
+	return Palettes{BG: palette, OBP0: palette, OBP1: palette}
+}
+
+// DefaultPalettes devuelve la paleta gris en las tres capas
+func DefaultPalettes() Palettes {
+	return builtinPalettes["grey"]
+}
+
+// BuiltinPalettes devuelve una de las paletas incluidas (ver PaletteNames)
+func BuiltinPalettes(name string) (Palettes, bool) {
+	palettes, ok := builtinPalettes[strings.ToLower(name)]
+	return palettes, ok
+}
+
+// ParsePalettes interpreta el nombre de una paleta incluida o una lista de
+// colores hexadecimales RRGGBB separados por comas o espacios: 4 colores se
+// usan en las tres capas y 12 colores se reparten entre BG, OBP0 y OBP1.
+func ParsePalettes(spec string) (Palettes, error) {
+	if palettes, ok := BuiltinPalettes(strings.TrimSpace(spec)); ok {
+		return palettes, nil
+	}
+	colors, err := parseColors(spec)
+	if err != nil {
+		return Palettes{}, err
+	}
+	var palettes Palettes
+	switch len(colors) {
+	case 4:
+		var palette Palette
+		copy(palette[:], colors)
+		palettes = uniform(palette)
+	case 12:
+		copy(palettes.BG[:], colors[0:4])
+		copy(palettes.OBP0[:], colors[4:8])
+		copy(palettes.OBP1[:], colors[8:12])
+	default:
+		return Palettes{}, fmt.Errorf("paleta %q: se esperaban 4 o 12 colores, hay %d", spec, len(colors))
+	}
+	return palettes, nil
+}
+
+// LoadPalettes lee un archivo de paleta. Cada línea indica la capa y sus
+// 4 colores; las capas que no aparecen usan los colores de "bg":
+//
+//	; Comentario
+//	bg   = e0f8d0 88c070 346856 081820
+//	obp0 = ffffff ffad63 833100 000000
+//	obp1 = ffffff 63a5ff 0000ff 000000
+//
+// Una línea sin "capa =" se aplica a las tres capas.
+func LoadPalettes(path string) (Palettes, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Palettes{}, err
+	}
+	defer file.Close()
+
+	var palettes Palettes
+	var hasBG, hasOBP0, hasOBP1 bool
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		layer := ""
+		if key, value, ok := strings.Cut(line, "="); ok {
+			layer = strings.ToLower(strings.TrimSpace(key))
+			line = value
+		}
+		colors, err := parseColors(line)
+		if err == nil && len(colors) != 4 {
+			err = fmt.Errorf("se esperaban 4 colores, hay %d", len(colors))
+		}
+		if err != nil {
+			return Palettes{}, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		var palette Palette
+		copy(palette[:], colors)
+		switch layer {
+		case "":
+			palettes = uniform(palette)
+			hasBG, hasOBP0, hasOBP1 = true, true, true
+		case "bg":
+			palettes.BG, hasBG = palette, true
+		case "obp0":
+			palettes.OBP0, hasOBP0 = palette, true
+		case "obp1":
+			palettes.OBP1, hasOBP1 = palette, true
+		default:
+			return Palettes{}, fmt.Errorf("%s:%d: capa desconocida %q", path, lineNumber, layer)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Palettes{}, err
+	}
+	if !hasBG {
+		return Palettes{}, fmt.Errorf("%s: falta la paleta bg", path)
+	}
+	if !hasOBP0 {
+		palettes.OBP0 = palettes.BG
+	}
+	if !hasOBP1 {
+		palettes.OBP1 = palettes.BG
+	}
+	return palettes, nil
+}
+
+func parseColors(s string) ([]Pixel, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	colors := make([]Pixel, 0, len(fields))
+	for _, field := range fields {
+		hex := strings.TrimPrefix(field, "#")
+		value, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return nil, fmt.Errorf("color inválido %q", field)
+		}
+		colors = append(colors, rgbPixel(uint32(value)))
+	}
+	return colors, nil
+}
+
+// SetPalettes cambia las paletas RGB; se puede llamar en cualquier momento
+// y afecta a los píxeles dibujados desde entonces
+func (ppu *PPU) SetPalettes(palettes Palettes) {
+	ppu.palettes = palettes
+}
+
+func (ppu *PPU) Palettes() Palettes {
+	return ppu.palettes
+}
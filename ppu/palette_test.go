@@ -0,0 +1,90 @@
+package ppu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParsePalettes(t *testing.T) {
+	palettes, err := ParsePalettes("green")
+	if err != nil || palettes.BG[0] != builtinPalettes["green"].BG[0] || palettes.OBP1 != palettes.BG {
+		t.Fatalf("paleta incluida: %v %v", palettes, err)
+	}
+
+	palettes, err = ParsePalettes("#ffffff,aaaaaa,555555,000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if palettes.BG[1] != (Pixel{R: 0xAA, G: 0xAA, B: 0xAA, A: 0xFF}) || palettes.OBP0 != palettes.BG {
+		t.Fatalf("4 colores: %v", palettes)
+	}
+
+	palettes, err = ParsePalettes("000000 000000 000000 000000 ff0000 ff0000 ff0000 ff0000 0000ff 0000ff 0000ff 0000ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if palettes.OBP0[2].R != 0xFF || palettes.OBP1[3].B != 0xFF {
+		t.Fatalf("12 colores: %v", palettes)
+	}
+
+	for _, spec := range []string{"", "rojo", "ffffff,000000", "gggggg,000000,000000,000000"} {
+		if _, err := ParsePalettes(spec); err == nil {
+			t.Errorf("%q debería fallar", spec)
+		}
+	}
+}
+
+func TestLoadPalettes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paleta.txt")
+	data := "; Paleta de prueba\nbg = e0f8d0 88c070 346856 081820\nobp1 = ffffff 63a5ff 0000ff 000000\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	palettes, err := LoadPalettes(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if palettes.BG[0] != (Pixel{R: 0xE0, G: 0xF8, B: 0xD0, A: 0xFF}) {
+		t.Fatalf("bg = %v", palettes.BG)
+	}
+	if palettes.OBP0 != palettes.BG {
+		t.Fatalf("obp0 debería usar bg: %v", palettes.OBP0)
+	}
+	if palettes.OBP1[2] != (Pixel{B: 0xFF, A: 0xFF}) {
+		t.Fatalf("obp1 = %v", palettes.OBP1)
+	}
+}
+
+func TestSetPalettesPerLayer(t *testing.T) {
+	p, b := newTestPPU()
+	for row := range 8 {
+		b.VRAM[2*16+row*2] = 0xFF // Tile 2: color 1
+	}
+	b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+	b.Write(OBP1Register, 0xE4)
+	writeOAM(b, 0, 16, 8+8, 2, 0)
+	writeOAM(b, 1, 16, 8+24, 2, 0x10) // OBP1
+
+	red := Pixel{R: 0xFF, A: 0xFF}
+	blue := Pixel{B: 0xFF, A: 0xFF}
+	palettes := DefaultPalettes()
+	palettes.OBP0[1] = red
+	palettes.OBP1[1] = blue
+	p.SetPalettes(palettes)
+	runToLine(t, p, b, 5)
+
+	at := func(x int) Pixel {
+		i := getFramebufferIndex(x, 4)
+		return Pixel{R: p.Framebuffer[i], G: p.Framebuffer[i+1], B: p.Framebuffer[i+2], A: p.Framebuffer[i+3]}
+	}
+	if got := at(40); got != palettes.BG[0] {
+		t.Fatalf("fondo = %v", got)
+	}
+	if got := at(10); got != red {
+		t.Fatalf("sprite OBP0 = %v", got)
+	}
+	if got := at(26); got != blue {
+		t.Fatalf("sprite OBP1 = %v", got)
+	}
+}
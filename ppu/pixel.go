@@ -6,12 +6,3 @@ type Pixel struct {
 	B byte
 	A byte
 }
-
-func newPixel(r, g, b, a byte) *Pixel {
-	return &Pixel{
-		R: r,
-		G: g,
-		B: b,
-		A: a,
-	}
-}
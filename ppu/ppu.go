@@ -1,6 +1,8 @@
 package ppu
 
 import (
+	"image"
+
 	"github.com/deybismelendez/liteboy/bus"
 )
 
@@ -11,67 +13,97 @@ const (
 	SCXRegister  = 0xFF43
 	LYRegister   = 0xFF44
 	LYCRegister  = 0xFF45
+	BGPRegister  = 0xFF47
+	OBP0Register = 0xFF48
+	OBP1Register = 0xFF49
 	WYRegister   = 0xFF4A // Window Y Position
 	WXRegister   = 0xFF4B // Window X Position (el valor real en pantalla es WX - 7)
 	ScreenWidth  = 160
 	ScreenHeight = 144
 )
 
-var WhiteColor Pixel = Pixel{R: 0xEE, G: 0xEE, B: 0xEE, A: 0xFF}
-
 type PPU struct {
-	bus                  *bus.Bus
-	Framebuffer          []byte
-	cycles               int
+	bus         *bus.Bus
+	Framebuffer []byte // Píxeles RGBA de la pantalla
+	// Tono (0-3) y capa de cada píxel de la pantalla, 160x144 sin importar la
+	// paleta RGB; sirven para comparar frames o leer la pantalla desde un bot
+	Shades               []byte
+	Layers               []Layer
+	cycles               int // Dots transcurridos en la línea actual
 	spritesOnCurrentLine []*Sprite
-	pixelFIFO            []*Pixel // FIFO para los píxeles
-	fifoSize             int
-	windowLineCounter    uint16
+	// Window (ver window.go)
+	windowLineCounter uint16 // Línea de la window, solo avanza si se dibujó
+	windowWY          bool   // WY coincidió con LY en este frame
+	windowWX166       bool   // WX=166 en la línea anterior
+	// Estado del modo 3
+	ly           byte // Línea actual (LY puede valer 0 antes, en la línea 153)
+	lx           int  // Siguiente columna de la pantalla a dibujar
+	bgFIFO       pixelFIFO
+	objFIFO      pixelFIFO
+	fetcher      fetcher
+	discard      int     // Píxeles del fondo descartados por SCX o WX < 7
+	vramDelay    int     // Dots restantes del primer fetch descartado
+	objFetch     *Sprite // Sprite que se está leyendo, nil si ninguno
+	objWait      int     // Dots a esperar que termine el fetcher de fondo
+	objTile      int     // Último tile de fondo por el que esperó un sprite
+	objDots      int     // Dots restantes de la lectura del sprite
+	windowActive bool    // El fetcher está leyendo la window
+	windowDrawn  bool    // La window apareció en esta línea
+	// Índice de color (0-3) del fondo/window en cada columna de la línea,
+	// antes de BGP (ver priority.go)
+	bgLine       [ScreenWidth]byte
+	palettes     Palettes
+	hiddenLayers [4]bool // Capas ocultas con SetLayerVisible
+	// Encendido y apagado del LCD
+	lcdOn      bool // Estado de LCDC bit 7 en el dot anterior
+	firstLine  bool // Primera línea tras encender el LCD: sin modo 2
+	blankFrame bool // Primer frame tras encender el LCD: no se muestra
+	offDots    int  // Dots transcurridos con el LCD apagado
+	// Terminó un frame: empezó la línea 144 o, con el LCD apagado, pasó el
+	// tiempo de un frame (ver FrameReady)
+	frameReady bool
+	// Línea de interrupción STAT: OR de las fuentes activas en STAT. La
+	// interrupción solo se pide en el flanco de subida.
+	statLine  bool
+	vblankOAM bool // Al entrar en VBlank también cuenta la fuente de modo 2
 }
 
 func NewPPU(b *bus.Bus) *PPU {
-	return &PPU{
+	ppu := &PPU{
 		bus:         b,
 		Framebuffer: make([]byte, ScreenWidth*ScreenHeight*4),
-		pixelFIFO:   make([]*Pixel, 0, ScreenWidth),
-		fifoSize:    ScreenWidth}
-}
-
-// Función para agregar un píxel a la FIFO
-func (ppu *PPU) addPixelToFIFO(pixel *Pixel) {
-	if len(ppu.pixelFIFO) < ppu.fifoSize {
-		ppu.pixelFIFO = append(ppu.pixelFIFO, pixel)
+		Shades:      make([]byte, ScreenWidth*ScreenHeight),
+		Layers:      make([]Layer, ScreenWidth*ScreenHeight),
+		palettes:    DefaultPalettes(),
+		// El boot ROM entrega el control en la línea 153 con el LCD
+		// encendido (STAT = 0x85, LY = 0 por el adelanto de LY)
+		ly:    153,
+		lcdOn: true,
 	}
+	b.OnOAMAccess = ppu.onOAMAccess
+	return ppu
 }
 
-// Función para extraer un píxel de la FIFO y colocarlo en el framebuffer
-func (ppu *PPU) popPixelFromFIFO(x, y int) {
-	pixel := ppu.pixelFIFO[0]
-	ppu.pixelFIFO = ppu.pixelFIFO[1:]
-	for i := 1; i < 4; i++ {
-		ppu.Framebuffer[getFramebufferIndex(x, y)] = pixel.R
-		ppu.Framebuffer[getFramebufferIndex(x, y)+1] = pixel.G
-		ppu.Framebuffer[getFramebufferIndex(x, y)+2] = pixel.B
-		ppu.Framebuffer[getFramebufferIndex(x, y)+3] = pixel.A
+// Image devuelve una copia de la pantalla actual (160x144 RGBA)
+func (ppu *PPU) Image() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+	copy(img.Pix, ppu.Framebuffer)
+	return img
+}
 
+func (ppu *PPU) setPixel(x, y int, shade byte, layer Layer, pixel Pixel) {
+	if ppu.blankFrame {
+		return
 	}
+	ppu.Shades[y*ScreenWidth+x] = shade
+	ppu.Layers[y*ScreenWidth+x] = layer
+	i := getFramebufferIndex(x, y)
+	ppu.Framebuffer[i] = pixel.R
+	ppu.Framebuffer[i+1] = pixel.G
+	ppu.Framebuffer[i+2] = pixel.B
+	ppu.Framebuffer[i+3] = pixel.A
 }
 
 func getFramebufferIndex(x, y int) int {
 	return (y*ScreenWidth + x) * 4
 }
-
-func getColorFromPalette(color byte) *Pixel {
-	switch color {
-	case 0:
-		return newPixel(0xEE, 0xEE, 0xEE, 0xFF) // blanco
-	case 1:
-		return newPixel(0xAA, 0xAA, 0xAA, 0xFF) // gris claro
-	case 2:
-		return newPixel(0x55, 0x55, 0x55, 0xFF) // gris oscuro
-	case 3:
-		return newPixel(0x00, 0x00, 0x00, 0xFF) // negro
-	default:
-		panic("No se reconoce color")
-	}
-}
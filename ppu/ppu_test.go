@@ -0,0 +1,213 @@
+package ppu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+func newTestPPU() (*PPU, *bus.Bus) {
+	b := bus.NewBus(nil)
+	b.Write(BGPRegister, 0xE4) // 3-2-1-0
+	b.Write(OBP0Register, 0xE4)
+	return NewPPU(b), b
+}
+
+// runToLine avanza hasta el comienzo del modo 2 de la línea indicada
+func runToLine(t *testing.T, p *PPU, b *bus.Bus, ly byte) {
+	t.Helper()
+	for range 2 * 154 * 456 {
+		if p.getMode() == ModeOAM && b.Read(LYRegister) == ly && p.cycles == 0 {
+			return
+		}
+		p.Step(1)
+	}
+	t.Fatalf("no se llegó a la línea %d", ly)
+}
+
+// mode3Length devuelve la duración en dots del modo 3 de la línea indicada
+func mode3Length(t *testing.T, p *PPU, b *bus.Bus, ly byte) int {
+	t.Helper()
+	runToLine(t, p, b, ly)
+	for p.getMode() != ModeVRAM {
+		p.Step(1)
+	}
+	dots := 0
+	for p.getMode() == ModeVRAM {
+		p.Step(1)
+		dots++
+	}
+	return dots
+}
+
+func writeOAM(b *bus.Bus, index int, y, x, tile, attr byte) {
+	copy(b.OAM[index*4:], []byte{y, x, tile, attr})
+}
+
+func TestMode3Length(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(b *bus.Bus)
+		want  int
+	}{
+		{"sin scroll", func(b *bus.Bus) {}, 172},
+		{"SCX=3", func(b *bus.Bus) { b.Write(SCXRegister, 3) }, 175},
+		{"SCX=8", func(b *bus.Bus) { b.Write(SCXRegister, 8) }, 172},
+		{"window", func(b *bus.Bus) {
+			b.Write(LCDCRegister, 0x91|LCDCFlagWindowEnable)
+			b.Write(WXRegister, 87)
+		}, 178},
+		{"sprite en X=8", func(b *bus.Bus) {
+			b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+			writeOAM(b, 0, 16+10, 8, 0, 0)
+		}, 183},
+		{"sprite en X=13", func(b *bus.Bus) {
+			b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+			writeOAM(b, 0, 16+10, 13, 0, 0)
+		}, 178},
+		{"dos sprites en X=8", func(b *bus.Bus) {
+			b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+			writeOAM(b, 0, 16+10, 8, 0, 0)
+			writeOAM(b, 1, 16+10, 8, 0, 0)
+		}, 189},
+		{"sprite en X=4", func(b *bus.Bus) {
+			b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+			writeOAM(b, 0, 16+10, 4, 0, 0)
+		}, 179},
+		{"sprites en X=0 y X=8", func(b *bus.Bus) {
+			b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+			writeOAM(b, 0, 16+10, 0, 0, 0)
+			writeOAM(b, 1, 16+10, 8, 0, 0)
+		}, 194},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, b := newTestPPU()
+			c.setup(b)
+			if got := mode3Length(t, p, b, 10); got != c.want {
+				t.Fatalf("modo 3 = %d dots, se esperaban %d", got, c.want)
+			}
+		})
+	}
+}
+
+func shadeAt(p *PPU, x, y int) byte {
+	r := p.Framebuffer[getFramebufferIndex(x, y)]
+	for shade := byte(0); shade < 4; shade++ {
+		if p.palettes.BG[shade].R == r {
+			return shade
+		}
+	}
+	return 0xFF
+}
+
+func TestRenderBackgroundAndSprite(t *testing.T) {
+	p, b := newTestPPU()
+	// Tile 1: todas las filas con color 3 en la mitad izquierda
+	for row := range 8 {
+		b.VRAM[16+row*2] = 0xF0
+		b.VRAM[16+row*2+1] = 0xF0
+	}
+	// Tile 2 (sprite): color 1 en todo el tile
+	for row := range 8 {
+		b.VRAM[32+row*2] = 0xFF
+	}
+	b.VRAM[0x1800] = 1 // Primer tile del mapa 9800
+	b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+	writeOAM(b, 0, 16+4, 8+16, 2, 0)   // Sprite sobre el fondo
+	writeOAM(b, 1, 16+4, 8+2, 2, 0x80) // Detrás del fondo
+
+	runToLine(t, p, b, 5)
+	if got := shadeAt(p, 0, 4); got != 3 {
+		t.Fatalf("fondo en (0,4) = %d, se esperaba 3", got)
+	}
+	if got := shadeAt(p, 12, 4); got != 0 {
+		t.Fatalf("fondo en (12,4) = %d, se esperaba 0", got)
+	}
+	if got := shadeAt(p, 16, 4); got != 1 {
+		t.Fatalf("sprite en (16,4) = %d, se esperaba 1", got)
+	}
+	// El sprite con prioridad BG solo se ve sobre el color 0 del fondo
+	if got := shadeAt(p, 2, 4); got != 3 {
+		t.Fatalf("sprite detrás del fondo en (2,4) = %d, se esperaba 3", got)
+	}
+	if got := shadeAt(p, 6, 4); got != 1 {
+		t.Fatalf("sprite detrás del fondo en (6,4) = %d, se esperaba 1", got)
+	}
+}
+
+func TestRenderMidScanlinePaletteChange(t *testing.T) {
+	p, b := newTestPPU()
+	for i := range 16 {
+		b.VRAM[16+i] = 0xFF // Tile 1 con color 3
+	}
+	for i := range 32 * 32 {
+		b.VRAM[0x1800+i] = 1
+	}
+	runToLine(t, p, b, 20)
+	for p.getMode() != ModeVRAM {
+		p.Step(1)
+	}
+	// 12 dots de fetch inicial + 80 píxeles
+	p.Step(12 + 80)
+	b.Write(BGPRegister, 0x00)
+	runToLine(t, p, b, 21)
+	if got := shadeAt(p, 10, 20); got != 3 {
+		t.Fatalf("antes del cambio de BGP = %d, se esperaba 3", got)
+	}
+	if got := shadeAt(p, 150, 20); got != 0 {
+		t.Fatalf("después del cambio de BGP = %d, se esperaba 0", got)
+	}
+}
+
+func TestShadesAndLayers(t *testing.T) {
+	p, b := newTestPPU()
+	for row := range 8 {
+		b.VRAM[16+row*2] = 0xFF   // Tile 1: color 1
+		b.VRAM[32+row*2+1] = 0xFF // Tile 2: color 2
+	}
+	for i := range 32 * 32 {
+		b.VRAM[0x1C00+i] = 2 // Mapa 9C00 para la window
+	}
+	b.Write(BGPRegister, 0x1B) // Invertida: color 0 = tono 3
+	b.Write(OBP1Register, 0xE4)
+	b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay|LCDCFlagWindowEnable|LCDCFlagWindowTileMap)
+	b.Write(WXRegister, 7+80)
+	writeOAM(b, 0, 16, 8+8, 1, 0)
+	writeOAM(b, 1, 16, 8+24, 1, 0x10)
+	p.SetPalettes(Palettes{}) // Los tonos no dependen de la paleta RGB
+	runToLine(t, p, b, 5)
+
+	cases := []struct {
+		x     int
+		shade byte
+		layer Layer
+	}{
+		{0, 3, LayerBG},
+		{10, 1, LayerOBJ0},
+		{28, 1, LayerOBJ1},
+		{100, 1, LayerWindow},
+	}
+	for _, c := range cases {
+		if shade, layer := p.ShadeAt(c.x, 4), p.LayerAt(c.x, 4); shade != c.shade || layer != c.layer {
+			t.Errorf("x=%d: tono %d capa %v, se esperaba %d %v", c.x, shade, layer, c.shade, c.layer)
+		}
+	}
+}
+
+func TestImageCopiesFramebuffer(t *testing.T) {
+	p, b := newTestPPU()
+	runToLine(t, p, b, 5)
+	img := p.Image()
+	if img.Bounds().Dx() != ScreenWidth || img.Bounds().Dy() != ScreenHeight {
+		t.Fatalf("tamaño %v", img.Bounds())
+	}
+	r, _, _, _ := img.At(0, 0).RGBA()
+	if byte(r>>8) != p.Framebuffer[0] {
+		t.Fatal("la imagen no coincide con el framebuffer")
+	}
+	p.Framebuffer[0] ^= 0xFF
+	if r2, _, _, _ := img.At(0, 0).RGBA(); r2 != r {
+		t.Fatal("la imagen debería ser una copia")
+	}
+}
@@ -0,0 +1,33 @@
+package ppu
+
+// Reglas de prioridad de sprites del DMG. Se aplican sobre índices de color
+// (0-3 dentro del tile), nunca sobre el color final, así que no dependen de
+// BGP, OBP0/OBP1 ni de la paleta RGB elegida.
+//
+//  1. Entre sprites gana el de menor X; con la misma X, el de menor índice
+//     OAM. Un píxel transparente (color 0) deja ver al siguiente sprite.
+//  2. El color 0 de un sprite es transparente.
+//  3. Con el atributo BG over OBJ (bit 7), el sprite solo se ve donde el
+//     fondo/window tiene color 0. Si el sprite ganador queda oculto por el
+//     fondo, los sprites de menor prioridad tampoco se ven.
+//  4. Con LCDC bit 0 apagado el fondo y la window usan el color 0.
+//
+// El índice de color del fondo/window de cada columna se guarda en bgLine al
+// sacarlo de la FIFO, y la regla 3 se resuelve con ese buffer.
+
+// spriteHasPriority indica si a se dibuja por encima de b (regla 1)
+func spriteHasPriority(a, b *Sprite) bool {
+	if a.X != b.X {
+		return a.X < b.X
+	}
+	return a.OAMIndex < b.OAMIndex
+}
+
+// objWins indica si el píxel del sprite ganador se dibuja sobre el fondo o
+// la window en la columna x de la línea actual (reglas 2 y 3)
+func (ppu *PPU) objWins(obj fifoPixel, x int) bool {
+	if obj.color == 0 {
+		return false
+	}
+	return !obj.priority || ppu.bgLine[x] == 0
+}
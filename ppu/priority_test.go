@@ -0,0 +1,120 @@
+package ppu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+// Tiles de prueba: 1 = color 3, 2 = color 1, 3 = color 2,
+// 4 = mitad izquierda transparente y mitad derecha color 1
+func writePriorityTiles(b *bus.Bus) {
+	for row := range 8 {
+		b.VRAM[1*16+row*2] = 0xFF
+		b.VRAM[1*16+row*2+1] = 0xFF
+		b.VRAM[2*16+row*2] = 0xFF
+		b.VRAM[3*16+row*2+1] = 0xFF
+		b.VRAM[4*16+row*2] = 0x0F
+	}
+}
+
+// renderLine dibuja la línea 4 con la configuración indicada
+func renderLine(t *testing.T, setup func(b *bus.Bus)) *PPU {
+	t.Helper()
+	p, b := newTestPPU()
+	writePriorityTiles(b)
+	b.Write(LCDCRegister, 0x91|LCDCFlagOBJDisplay)
+	setup(b)
+	runToLine(t, p, b, 5)
+	return p
+}
+
+func TestSpriteBehindBGIgnoresPalette(t *testing.T) {
+	// BGP mapea todos los colores a negro: el color 0 del fondo sigue
+	// siendo transparente para un sprite con BG over OBJ
+	p := renderLine(t, func(b *bus.Bus) {
+		b.Write(BGPRegister, 0xFF)
+		writeOAM(b, 0, 16, 8+8, 2, 0x80)
+	})
+	if got := shadeAt(p, 8, 4); got != 1 {
+		t.Fatalf("sprite sobre color 0 = %d, se esperaba 1", got)
+	}
+}
+
+func TestLowerXWins(t *testing.T) {
+	p := renderLine(t, func(b *bus.Bus) {
+		writeOAM(b, 0, 16, 8+12, 3, 0) // Color 2, X mayor
+		writeOAM(b, 1, 16, 8+8, 2, 0)  // Color 1, X menor
+	})
+	if got := shadeAt(p, 13, 4); got != 1 {
+		t.Fatalf("superposición = %d, se esperaba el sprite de menor X", got)
+	}
+	if got := shadeAt(p, 17, 4); got != 2 {
+		t.Fatalf("resto del sprite de mayor X = %d, se esperaba 2", got)
+	}
+}
+
+func TestSameXLowerOAMIndexWins(t *testing.T) {
+	p := renderLine(t, func(b *bus.Bus) {
+		writeOAM(b, 0, 16, 8+8, 3, 0)
+		writeOAM(b, 1, 16, 8+8, 2, 0)
+	})
+	if got := shadeAt(p, 10, 4); got != 2 {
+		t.Fatalf("misma X = %d, se esperaba el sprite de menor índice OAM", got)
+	}
+}
+
+func TestTransparentSpritePixelShowsNextSprite(t *testing.T) {
+	p := renderLine(t, func(b *bus.Bus) {
+		writeOAM(b, 0, 16, 8+8, 4, 0)
+		writeOAM(b, 1, 16, 8+8, 3, 0)
+	})
+	if got := shadeAt(p, 9, 4); got != 2 {
+		t.Fatalf("píxel transparente = %d, se esperaba el segundo sprite", got)
+	}
+	if got := shadeAt(p, 13, 4); got != 1 {
+		t.Fatalf("píxel opaco = %d, se esperaba el primer sprite", got)
+	}
+}
+
+func TestHiddenWinnerHidesLowerPrioritySprites(t *testing.T) {
+	p := renderLine(t, func(b *bus.Bus) {
+		b.VRAM[0x1801] = 1 // Fondo color 3 en x = 8..15
+		writeOAM(b, 0, 16, 8+8, 2, 0x80)
+		writeOAM(b, 1, 16, 8+8, 3, 0)
+	})
+	if got := shadeAt(p, 10, 4); got != 3 {
+		t.Fatalf("sprite oculto por el fondo = %d, se esperaba el fondo", got)
+	}
+}
+
+func TestBGDisabledUsesColorZero(t *testing.T) {
+	p := renderLine(t, func(b *bus.Bus) {
+		b.VRAM[0x1801] = 1
+		b.Write(BGPRegister, 0xE7) // Color 0 = 3
+		b.Write(LCDCRegister, 0x90|LCDCFlagOBJDisplay)
+		writeOAM(b, 0, 16, 8+8, 2, 0x80)
+	})
+	if got := shadeAt(p, 10, 4); got != 1 {
+		t.Fatalf("sprite con fondo apagado = %d, se esperaba 1", got)
+	}
+	if got := shadeAt(p, 40, 4); got != 3 {
+		t.Fatalf("fondo apagado = %d, se esperaba el color 0 de BGP", got)
+	}
+}
+
+func TestBGLineHoldsColorIndexes(t *testing.T) {
+	p := renderLine(t, func(b *bus.Bus) {
+		b.VRAM[0x1801] = 3         // Color 2 en x = 8..15
+		b.Write(BGPRegister, 0x00) // Todos los colores en blanco
+		writeOAM(b, 0, 16, 8+8, 2, 0x80)
+		writeOAM(b, 1, 16, 8+24, 2, 0x80)
+	})
+	if p.bgLine[10] != 2 || p.bgLine[30] != 0 {
+		t.Fatalf("índices de la línea: %d y %d, se esperaba 2 y 0", p.bgLine[10], p.bgLine[30])
+	}
+	// Con el mismo tono en pantalla, solo el sprite sobre el color 0 se ve
+	if shadeAt(p, 10, 4) != 0 || shadeAt(p, 26, 4) != 1 {
+		t.Fatalf("sprites detrás del fondo: %d y %d", shadeAt(p, 10, 4), shadeAt(p, 26, 4))
+	}
+}
@@ -5,13 +5,15 @@ const MaxSpritesPerLine = 10
 type Sprite struct {
 	X, Y, TileIndex, Atributes byte
 	OAMIndex                   uint16
+	fetched                    bool // Ya se mezcló en la FIFO de esta línea
 }
 
-func newSprite(x, y, tileIndex, atributes byte) *Sprite {
+func newSprite(x, y, tileIndex, atributes byte, oamIndex uint16) *Sprite {
 	return &Sprite{
 		X:         x,
 		Y:         y,
 		TileIndex: tileIndex,
 		Atributes: atributes,
+		OAMIndex:  oamIndex,
 	}
 }
@@ -9,21 +9,13 @@ const (
 	ModeVRAM   = 3
 )
 
-func (ppu *PPU) isLYCInterruptEnabled() bool {
-	return ppu.bus.Read(STATRegister)&0x40 != 0
-}
-
-func (ppu *PPU) isOAMInterruptEnabled() bool {
-	return ppu.bus.Read(STATRegister)&0x20 != 0
-}
-
-func (ppu *PPU) isVBlankInterruptEnabled() bool {
-	return ppu.bus.Read(STATRegister)&0x10 != 0
-}
-
-func (ppu *PPU) isHBlankInterruptEnabled() bool {
-	return ppu.bus.Read(STATRegister)&0x08 != 0
-}
+// Fuentes de la interrupción STAT (bits 3-6)
+const (
+	STATSourceHBlank = 1 << 3
+	STATSourceVBlank = 1 << 4
+	STATSourceOAM    = 1 << 5
+	STATSourceLYC    = 1 << 6
+)
 
 func (ppu *PPU) setCoincidenceFlag(set bool) {
 	if set {
@@ -32,12 +24,37 @@ func (ppu *PPU) setCoincidenceFlag(set bool) {
 		ppu.bus.Write(STATRegister, ppu.bus.Read(STATRegister)&^0x04) // Clear bit 2 (bitwise AND NOT)
 	}
 }
+
 func (ppu *PPU) updateCoincidenceFlag() {
+	if ppu.bus.LYChanging {
+		ppu.setCoincidenceFlag(false)
+		return
+	}
 	ly := ppu.bus.Read(LYRegister)
 	lyc := ppu.bus.Read(LYCRegister)
-	match := ly == lyc
-	ppu.setCoincidenceFlag(match)
-	if match && ppu.isLYCInterruptEnabled() {
+	ppu.setCoincidenceFlag(ly == lyc)
+}
+
+// statSources indica si alguna de las fuentes habilitadas en stat está activa
+func (ppu *PPU) statSources(stat byte) bool {
+	mode := stat & 0x03
+	return (stat&STATSourceLYC != 0 && stat&0x04 != 0) ||
+		(stat&STATSourceHBlank != 0 && mode == ModeHBlank) ||
+		(stat&STATSourceVBlank != 0 && mode == ModeVBlank) ||
+		(stat&STATSourceOAM != 0 && (mode == ModeOAM || ppu.vblankOAM))
+}
+
+// updateStatLine recalcula la línea de interrupción STAT en cada dot
+func (ppu *PPU) updateStatLine() {
+	ppu.updateCoincidenceFlag()
+	stat := ppu.bus.Read(STATRegister)
+	ppu.setStatLine(ppu.statSources(stat))
+	ppu.vblankOAM = false
+}
+
+func (ppu *PPU) setStatLine(line bool) {
+	if line && !ppu.statLine {
 		ppu.requestInterrupt(InterruptSTAT)
 	}
+	ppu.statLine = line
 }
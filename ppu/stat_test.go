@@ -0,0 +1,128 @@
+package ppu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+const ifRegister = 0xFF0F
+
+// enableSTAT habilita fuentes de STAT sin tocar los bits de modo
+func enableSTAT(b *bus.Bus, sources byte) {
+	b.Write(STATRegister, b.Read(STATRegister)&0x07|sources)
+}
+
+// countSTAT cuenta las interrupciones STAT pedidas en los dots indicados
+func countSTAT(p *PPU, b *bus.Bus, dots int) int {
+	count := 0
+	for range dots {
+		b.Write(ifRegister, 0)
+		p.Step(1)
+		if b.Read(ifRegister)&(1<<InterruptSTAT) != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSTATVBlankSourceFiresOncePerFrame(t *testing.T) {
+	p, b := newTestPPU()
+	enableSTAT(b, STATSourceVBlank)
+	runToLine(t, p, b, 0)
+	if got := countSTAT(p, b, 154*456); got != 1 {
+		t.Fatalf("%d interrupciones STAT de VBlank en un frame, se esperaba 1", got)
+	}
+}
+
+func TestSTATLineBlocksConsecutiveSources(t *testing.T) {
+	p, b := newTestPPU()
+	// Modo 0 y modo 2: la línea sigue alta entre HBlank y el modo 2 de la
+	// línea siguiente, así que solo hay un flanco por línea
+	enableSTAT(b, STATSourceHBlank|STATSourceOAM)
+	runToLine(t, p, b, 10)
+	if got := countSTAT(p, b, 456); got != 1 {
+		t.Fatalf("%d interrupciones STAT en una línea, se esperaba 1", got)
+	}
+}
+
+func TestSTATLYCSource(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LYCRegister, 20)
+	enableSTAT(b, STATSourceLYC)
+	runToLine(t, p, b, 0)
+	if got := countSTAT(p, b, 154*456); got != 1 {
+		t.Fatalf("%d interrupciones de LYC, se esperaba 1", got)
+	}
+	if b.Read(STATRegister)&0x04 != 0 {
+		t.Fatal("LY no coincide con LYC en la línea 0")
+	}
+}
+
+func TestSTATLineKeptWhileLCDOff(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LYCRegister, 20)
+	enableSTAT(b, STATSourceLYC)
+	runToLine(t, p, b, 20)
+	p.Step(4)
+
+	// Con el LCD apagado la coincidencia no se actualiza y la línea sigue
+	// alta, así que al encenderlo con LY = LYC = 0 no hay flanco
+	b.Write(LCDCRegister, 0x11)
+	p.Step(4)
+	b.Write(LYCRegister, 0)
+	p.Step(4)
+	if b.Read(STATRegister)&0x04 == 0 {
+		t.Fatal("la coincidencia debería mantenerse con el LCD apagado")
+	}
+	b.Write(LCDCRegister, 0x91)
+	if got := countSTAT(p, b, 8); got != 0 {
+		t.Fatalf("%d interrupciones de LYC al encender el LCD, se esperaba 0", got)
+	}
+}
+
+func TestSTATOAMSourceAtLine144(t *testing.T) {
+	p, b := newTestPPU()
+	enableSTAT(b, STATSourceOAM)
+	runToLine(t, p, b, 0)
+	// 144 líneas con modo 2 y el comienzo de VBlank
+	if got := countSTAT(p, b, 154*456); got != 145 {
+		t.Fatalf("%d interrupciones de modo 2, se esperaban 145", got)
+	}
+}
+
+func TestSTATWriteQuirk(t *testing.T) {
+	p, b := newTestPPU()
+	runToLine(t, p, b, 10)
+	for p.getMode() != ModeHBlank {
+		p.Step(1)
+	}
+	b.Write(ifRegister, 0)
+	b.Client = bus.ClientCPU
+	b.Write(STATRegister, 0x00)
+	if b.Read(STATRegister)&0x03 != ModeHBlank {
+		t.Fatal("la CPU no debería poder cambiar los bits de modo")
+	}
+	p.Step(1)
+	if b.Read(ifRegister)&(1<<InterruptSTAT) == 0 {
+		t.Fatal("escribir STAT en HBlank debería pedir una interrupción en DMG")
+	}
+	b.TickSTAT()
+	if b.Read(STATRegister)&0x78 != 0 {
+		t.Fatal("después del ciclo de la escritura STAT debería tener el valor escrito")
+	}
+
+	// En modo 3 la escritura no tiene efecto
+	runToLine(t, p, b, 11)
+	for p.getMode() != ModeVRAM {
+		p.Step(1)
+	}
+	b.Write(ifRegister, 0)
+	b.Client = bus.ClientCPU
+	b.Write(STATRegister, 0x00)
+	p.Step(1)
+	b.TickSTAT()
+	if b.Read(ifRegister)&(1<<InterruptSTAT) != 0 {
+		t.Fatal("escribir STAT en modo 3 no debería pedir una interrupción")
+	}
+}
@@ -1,48 +1,113 @@
 package ppu
 
+// Dots de un frame completo (154 líneas de 456)
+const frameDots = 154 * 456
+
+// Dots que el PPU lleva de ventaja al encender el LCD. La CPU accede al bus
+// al comienzo de cada ciclo de máquina y ve al PPU 3 dots más adelante; los
+// cambios que caen en múltiplos de 4 dots no lo notan, pero el final de un
+// modo 3 alargado por SCX o por sprites sí.
+const lcdOnPhaseDots = 3
+
 func (ppu *PPU) Step(tCycles int) {
 	ppu.bus.Client = 1
 	if !ppu.isLCDEnabled() {
-		ppu.bus.Write(LYRegister, 0)
-		ppu.setMode(ModeHBlank)
+		if ppu.lcdOn {
+			ppu.turnOff()
+		}
+		// Con el LCD apagado la pantalla sigue entregando frames en blanco
+		ppu.offDots += tCycles
+		if ppu.offDots >= frameDots {
+			ppu.offDots -= frameDots
+			ppu.frameReady = true
+		}
 		return
 	}
+	if !ppu.lcdOn {
+		ppu.turnOn()
+	}
 
-	ppu.cycles += tCycles
-
-	switch ppu.getMode() {
-	case ModeOAM:
-		ppu.scanOAM()
-	case ModeVRAM:
-		ppu.runVRAM()
-	case ModeHBlank:
-		ppu.runHBlank()
-	case ModeVBlank:
-		ppu.runVBlank()
+	// El PPU avanza un dot por t-ciclo
+	for range tCycles {
+		ppu.cycles++
+		switch ppu.getMode() {
+		case ModeOAM:
+			ppu.scanOAM()
+		case ModeVRAM:
+			ppu.runVRAM()
+		case ModeHBlank:
+			ppu.runHBlank()
+		case ModeVBlank:
+			ppu.runVBlank()
+		}
+		ppu.updateStatLine()
 	}
 }
+
+// turnOff detiene el PPU: LY queda en 0, STAT en modo 0 y la pantalla en
+// blanco mientras el LCD esté apagado. La coincidencia de LYC y la línea de
+// interrupción STAT conservan su valor hasta que se vuelva a encender.
+func (ppu *PPU) turnOff() {
+	ppu.lcdOn = false
+	ppu.ly = 0
+	ppu.cycles = 0
+	ppu.offDots = 0
+	ppu.bus.LYChanging = false
+	ppu.bus.OAMScanDone = false
+	ppu.bus.Write(LYRegister, 0)
+	ppu.writeMode(ModeHBlank)
+	// Si se apaga durante el primer frame tras encenderlo, el blanco también
+	// tiene que llegar a la pantalla
+	ppu.blankFrame = false
+	white := ppu.palettes.BG[0]
+	for y := range ScreenHeight {
+		for x := range ScreenWidth {
+			ppu.setPixel(x, y, 0, LayerBG, white)
+		}
+	}
+}
+
+// turnOn arranca el PPU en la línea 0. Esa línea empieza en modo 0 en lugar
+// del modo 2 y el primer frame no llega a la pantalla.
+func (ppu *PPU) turnOn() {
+	ppu.lcdOn = true
+	ppu.ly = 0
+	ppu.cycles = lcdOnPhaseDots
+	ppu.firstLine = true
+	ppu.blankFrame = true
+	ppu.resetWindow()
+}
+
+// FrameReady indica si terminó un frame desde la última llamada. Con el LCD
+// encendido el frame termina al comenzar la línea 144; apagado, cada 70224
+// dots.
+func (ppu *PPU) FrameReady() bool {
+	ready := ppu.frameReady
+	ppu.frameReady = false
+	return ready
+}
+
 func (ppu *PPU) getMode() byte {
 	return ppu.bus.Read(STATRegister) & 0x03
 }
 
-func (ppu *PPU) setMode(mode byte) {
+// writeMode cambia los bits de modo de STAT sin pedir interrupciones
+func (ppu *PPU) writeMode(mode byte) {
 	stat := ppu.bus.Read(STATRegister)
 	stat = (stat &^ 0x03) | (mode & 0x03) // Bits 0-1 del STAT: modo actual
 	ppu.bus.Write(STATRegister, stat)
+}
 
-	switch mode {
-	case ModeHBlank:
-		if ppu.isHBlankInterruptEnabled() {
-			ppu.requestInterrupt(InterruptSTAT)
-		}
-	case ModeVBlank:
+// setMode cambia de modo; la interrupción STAT se calcula en updateStatLine
+func (ppu *PPU) setMode(mode byte) {
+	ppu.writeMode(mode)
+	if mode == ModeOAM {
+		ppu.checkWindowY()
+	}
+	if mode == ModeVBlank {
+		ppu.frameReady = true
 		ppu.requestInterrupt(InterruptVBlank)
-		if ppu.isVBlankInterruptEnabled() {
-			ppu.requestInterrupt(InterruptSTAT)
-		}
-	case ModeOAM:
-		if ppu.isOAMInterruptEnabled() {
-			ppu.requestInterrupt(InterruptSTAT)
-		}
+		// La fuente de modo 2 también se activa al comenzar la línea 144
+		ppu.vblankOAM = true
 	}
 }
@@ -0,0 +1,199 @@
+package ppu
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Visores de VRAM y OAM para depurar. Leen la memoria directamente, sin
+// pasar por las restricciones de acceso del bus, y usan BGP/OBP0/OBP1 con
+// las paletas RGB actuales.
+
+const (
+	TileCount       = 384 // Tiles de 0x8000-0x97FF
+	TilesPerRow     = 16
+	TileMapSize     = 256 // Lado en píxeles de un mapa de 32x32 tiles
+	OAMSpriteCount  = 40
+	OAMCellsPerRow  = 8
+	oamCellSize     = 16 // Celda de la tabla OAM (sprite de 8x16 con margen)
+	viewerTileBytes = 16
+)
+
+var (
+	// Rectángulo de la pantalla (SCX/SCY) sobre el mapa del fondo
+	ViewportColor = color.RGBA{R: 0xFF, A: 0xFF}
+	// Zona del mapa de la window que aparece en pantalla
+	WindowAreaColor = color.RGBA{B: 0xFF, A: 0xFF}
+)
+
+// OAMEntry es un sprite de la OAM tal como lo ve el visor
+type OAMEntry struct {
+	Index int
+	Y, X  byte // Coordenadas de la OAM (pantalla + 16 y + 8)
+	Tile  byte
+	Flags byte
+}
+
+func (e OAMEntry) BehindBG() bool { return e.Flags&0x80 != 0 }
+func (e OAMEntry) FlipY() bool    { return e.Flags&0x40 != 0 }
+func (e OAMEntry) FlipX() bool    { return e.Flags&0x20 != 0 }
+func (e OAMEntry) OBP1() bool     { return e.Flags&0x10 != 0 }
+
+// Visible indica si alguna parte del sprite cae dentro de la pantalla
+func (e OAMEntry) Visible(height byte) bool {
+	return int(e.X) > 0 && int(e.X) < ScreenWidth+8 &&
+		int(e.Y)+int(height) > 16 && int(e.Y) < ScreenHeight+16
+}
+
+func (e OAMEntry) String() string {
+	flags := ""
+	if e.BehindBG() {
+		flags += " detrás"
+	}
+	if e.FlipY() {
+		flags += " flipY"
+	}
+	if e.FlipX() {
+		flags += " flipX"
+	}
+	if e.OBP1() {
+		flags += " OBP1"
+	} else {
+		flags += " OBP0"
+	}
+	return fmt.Sprintf("%02d X=%3d Y=%3d tile=%02X flags=%02X%s", e.Index, e.X, e.Y, e.Tile, e.Flags, flags)
+}
+
+// OAMEntries devuelve los 40 sprites de la OAM
+func (ppu *PPU) OAMEntries() []OAMEntry {
+	entries := make([]OAMEntry, OAMSpriteCount)
+	for i := range entries {
+		oam := ppu.bus.OAM[i*4 : i*4+4]
+		entries[i] = OAMEntry{Index: i, Y: oam[0], X: oam[1], Tile: oam[2], Flags: oam[3]}
+	}
+	return entries
+}
+
+// tileColor devuelve el índice de color (0-3) de un píxel de un tile
+func (ppu *PPU) tileColor(tileAddr uint16, x, y int) byte {
+	i := tileAddr - 0x8000 + uint16(y)*2
+	low := ppu.bus.VRAM[i]
+	high := ppu.bus.VRAM[i+1]
+	bit := 7 - x
+	return (((high >> bit) & 1) << 1) | ((low >> bit) & 1)
+}
+
+func (ppu *PPU) bgColor(colorIndex byte) color.RGBA {
+	shade := (ppu.bus.Read(BGPRegister) >> (colorIndex * 2)) & 0x03
+	return pixelColor(ppu.palettes.BG[shade])
+}
+
+func pixelColor(p Pixel) color.RGBA {
+	return color.RGBA{R: p.R, G: p.G, B: p.B, A: p.A}
+}
+
+// TilesImage dibuja los 384 tiles de 0x8000-0x97FF en filas de 16 (128x192)
+func (ppu *PPU) TilesImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, TilesPerRow*8, TileCount/TilesPerRow*8))
+	for tile := range TileCount {
+		tileAddr := 0x8000 + uint16(tile)*viewerTileBytes
+		originX := (tile % TilesPerRow) * 8
+		originY := (tile / TilesPerRow) * 8
+		for y := range 8 {
+			for x := range 8 {
+				img.SetRGBA(originX+x, originY+y, ppu.bgColor(ppu.tileColor(tileAddr, x, y)))
+			}
+		}
+	}
+	return img
+}
+
+// TileMapImage dibuja el mapa de 32x32 tiles de 0x9800 o 0x9C00 con el
+// direccionamiento de tiles actual de LCDC. Si es el mapa del fondo marca el
+// rectángulo visible según SCX/SCY, y si es el de la window marca la parte
+// que aparece en pantalla según WX/WY.
+func (ppu *PPU) TileMapImage(mapAddr uint16) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, TileMapSize, TileMapSize))
+	for row := range 32 {
+		for column := range 32 {
+			tileIndex := ppu.bus.VRAM[mapAddr-0x8000+uint16(row*32+column)]
+			tileAddr := 0x8000 + uint16(tileIndex)*viewerTileBytes
+			if ppu.getBGAndWindowTileDataArea() != 0x8000 {
+				tileAddr = uint16(0x9000 + int(int8(tileIndex))*viewerTileBytes)
+			}
+			for y := range 8 {
+				for x := range 8 {
+					img.SetRGBA(column*8+x, row*8+y, ppu.bgColor(ppu.tileColor(tileAddr, x, y)))
+				}
+			}
+		}
+	}
+
+	if mapAddr == ppu.getBGTileMapArea() {
+		scx := int(ppu.bus.Read(SCXRegister))
+		scy := int(ppu.bus.Read(SCYRegister))
+		drawOutline(img, scx, scy, ScreenWidth, ScreenHeight, ViewportColor)
+	}
+	if mapAddr == ppu.getWindowTileMapArea() && ppu.isWindowEnabled() {
+		wx := int(ppu.bus.Read(WXRegister)) - 7
+		wy := int(ppu.bus.Read(WYRegister))
+		width := ScreenWidth - max(wx, 0)
+		height := ScreenHeight - wy
+		if width > 0 && height > 0 {
+			drawOutline(img, max(-wx, 0), 0, width, height, WindowAreaColor)
+		}
+	}
+	return img
+}
+
+// drawOutline dibuja el borde de un rectángulo que da la vuelta al mapa
+func drawOutline(img *image.RGBA, x, y, width, height int, c color.RGBA) {
+	for i := range width {
+		img.SetRGBA((x+i)%TileMapSize, y%TileMapSize, c)
+		img.SetRGBA((x+i)%TileMapSize, (y+height-1)%TileMapSize, c)
+	}
+	for i := range height {
+		img.SetRGBA(x%TileMapSize, (y+i)%TileMapSize, c)
+		img.SetRGBA((x+width-1)%TileMapSize, (y+i)%TileMapSize, c)
+	}
+}
+
+// OAMImage dibuja los 40 sprites de la OAM en una tabla de 8 columnas, cada
+// uno en una celda de 16x16 con sus flips y su paleta. El color 0 queda
+// transparente.
+func (ppu *PPU) OAMImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, OAMCellsPerRow*oamCellSize, OAMSpriteCount/OAMCellsPerRow*oamCellSize))
+	height := int(ppu.getObjHeight())
+	for _, entry := range ppu.OAMEntries() {
+		originX := (entry.Index%OAMCellsPerRow)*oamCellSize + 4
+		originY := (entry.Index / OAMCellsPerRow) * oamCellSize
+		tile := entry.Tile
+		if height == 16 {
+			tile &= 0xFE
+		}
+		palette, rgb := ppu.bus.Read(OBP0Register), &ppu.palettes.OBP0
+		if entry.OBP1() {
+			palette, rgb = ppu.bus.Read(OBP1Register), &ppu.palettes.OBP1
+		}
+		for y := range height {
+			line := y
+			if entry.FlipY() {
+				line = height - 1 - y
+			}
+			for x := range 8 {
+				column := x
+				if entry.FlipX() {
+					column = 7 - x
+				}
+				colorIndex := ppu.tileColor(0x8000+uint16(tile)*viewerTileBytes, column, line)
+				if colorIndex == 0 {
+					continue
+				}
+				shade := (palette >> (colorIndex * 2)) & 0x03
+				img.SetRGBA(originX+x, originY+y, pixelColor(rgb[shade]))
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,72 @@
+package ppu
+
+import "testing"
+
+func TestTilesImage(t *testing.T) {
+	p, b := newTestPPU()
+	// Tile 17 (fila 1, columna 1): columna 0 con color 3
+	for row := range 8 {
+		b.VRAM[17*16+row*2] = 0x80
+		b.VRAM[17*16+row*2+1] = 0x80
+	}
+	img := p.TilesImage()
+	if img.Bounds().Dx() != 128 || img.Bounds().Dy() != 192 {
+		t.Fatalf("tamaño %v", img.Bounds())
+	}
+	if got := img.RGBAAt(8, 8); got != pixelColor(p.palettes.BG[3]) {
+		t.Fatalf("píxel del tile = %v", got)
+	}
+	if got := img.RGBAAt(9, 8); got != pixelColor(p.palettes.BG[0]) {
+		t.Fatalf("píxel vacío = %v", got)
+	}
+}
+
+func TestTileMapImageOutlines(t *testing.T) {
+	p, b := newTestPPU()
+	b.Write(LCDCRegister, 0x91|LCDCFlagWindowEnable|LCDCFlagWindowTileMap)
+	b.Write(SCXRegister, 200)
+	b.Write(SCYRegister, 10)
+	b.Write(WXRegister, 7+60)
+	b.Write(WYRegister, 100)
+
+	bg := p.TileMapImage(0x9800)
+	if bg.RGBAAt(200, 10) != ViewportColor || bg.RGBAAt(100, 10) != ViewportColor {
+		t.Fatal("el rectángulo de SCX/SCY debería dar la vuelta al mapa")
+	}
+	if bg.RGBAAt(150, 10) == ViewportColor {
+		t.Fatal("fuera del rectángulo no debería haber borde")
+	}
+
+	window := p.TileMapImage(0x9C00)
+	if window.RGBAAt(0, 0) != WindowAreaColor || window.RGBAAt(99, 43) != WindowAreaColor {
+		t.Fatal("falta el borde de la window (100x44)")
+	}
+	if window.RGBAAt(100, 0) == WindowAreaColor {
+		t.Fatal("el borde de la window es demasiado ancho")
+	}
+}
+
+func TestOAMEntriesAndImage(t *testing.T) {
+	p, b := newTestPPU()
+	for row := range 8 {
+		b.VRAM[3*16+row*2] = 0x80 // Tile 3: columna 0 con color 1
+	}
+	writeOAM(b, 5, 16, 8, 3, 0x20) // X flip
+
+	entry := p.OAMEntries()[5]
+	if entry.X != 8 || entry.Y != 16 || entry.Tile != 3 || !entry.FlipX() || entry.OBP1() {
+		t.Fatalf("sprite 5 = %v", entry)
+	}
+	if !entry.Visible(8) || p.OAMEntries()[0].Visible(8) {
+		t.Fatal("visibilidad incorrecta")
+	}
+
+	img := p.OAMImage()
+	// Celda 5: columna 5 de la primera fila; con X flip la columna 0 queda a la derecha
+	if got := img.RGBAAt(5*16+4+7, 0); got != pixelColor(p.palettes.OBP0[1]) {
+		t.Fatalf("píxel del sprite = %v", got)
+	}
+	if got := img.RGBAAt(5*16+4, 0); got.A != 0 {
+		t.Fatalf("el color 0 debería ser transparente: %v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package ppu
+
+// Condiciones para que aparezca la window en DMG:
+//   - WY se compara con LY al comenzar cada línea (modo 2) con la window
+//     activada; una vez que coincidió vale para el resto del frame, aunque
+//     después cambie WY.
+//   - En el modo 3 la window empieza cuando la columna actual + 7 es igual a
+//     WX. Se vuelve a mirar LCDC bit 5 en cada dot, así que activarla o
+//     desactivarla a mitad de línea tiene efecto inmediato.
+//   - El contador de líneas de la window solo avanza en las líneas en las que
+//     se dibujó, así que al reactivarla sigue desde la línea en que quedó.
+//
+// Casos especiales de WX:
+//   - WX=1-6: la window empieza al comienzo de la línea y se recortan sus
+//     primeros 7-WX píxeles.
+//   - WX=0: la comparación ocurre mientras se descartan los píxeles del
+//     desplazamiento fino de SCX, y el recorte depende de SCX & 7.
+//   - WX=166: la window no aparece en la línea, pero al final de la línea
+//     queda activada y cubre toda la línea siguiente.
+
+// Píxeles de la window recortados con WX=0 según SCX & 7, tomados de SameBoy.
+// Los comprueba m3_window_timing_wx_0 de mealybug-tearoom (TestWindowROMs).
+var wx0Cut = [8]int{7, 9, 10, 11, 12, 13, 14, 14}
+
+const wx166 = 166
+
+// checkWindowY se llama al comenzar cada línea
+func (ppu *PPU) checkWindowY() {
+	if ppu.isWindowEnabled() && ppu.ly == ppu.bus.Read(WYRegister) {
+		ppu.windowWY = true
+	}
+}
+
+// resetWindow se llama al comenzar cada frame
+func (ppu *PPU) resetWindow() {
+	ppu.windowWY = false
+	ppu.windowWX166 = false
+	ppu.windowLineCounter = 0
+}
+
+// startLineWindow decide al comenzar el modo 3 si la window empieza antes del
+// primer píxel (WX < 7 o WX=166 en la línea anterior)
+func (ppu *PPU) startLineWindow() {
+	wx166 := ppu.windowWX166
+	ppu.windowWX166 = false
+	if !ppu.windowWY || !ppu.isWindowEnabled() {
+		return
+	}
+	wx := int(ppu.bus.Read(WXRegister))
+	switch {
+	case wx166:
+		ppu.startWindow(0)
+	case wx == 0:
+		ppu.startWindow(wx0Cut[ppu.bus.Read(SCXRegister)&0x07])
+	case wx < 7:
+		ppu.startWindow(7 - wx)
+	}
+}
+
+// updateWindow se llama en cada dot del modo 3 antes de avanzar el fetcher
+func (ppu *PPU) updateWindow() {
+	if !ppu.isWindowEnabled() {
+		if ppu.windowActive {
+			ppu.stopWindow()
+		}
+		return
+	}
+	if ppu.windowActive || !ppu.windowWY || ppu.discard > 0 {
+		return
+	}
+	wx := int(ppu.bus.Read(WXRegister))
+	if wx >= 7 && wx < wx166 && ppu.lx == wx-7 {
+		ppu.startWindow(0)
+	}
+}
+
+// endLineWindow se llama al terminar el modo 3
+func (ppu *PPU) endLineWindow() {
+	if ppu.windowDrawn {
+		ppu.windowLineCounter++
+	}
+	if ppu.windowWY && ppu.isWindowEnabled() && ppu.bus.Read(WXRegister) == wx166 {
+		ppu.windowWX166 = true
+	}
+}
+
+// startWindow reinicia el fetcher para dibujar la window desde la columna
+// actual, descartando los primeros cut píxeles
+func (ppu *PPU) startWindow(cut int) {
+	ppu.windowActive = true
+	ppu.windowDrawn = true
+	ppu.bgFIFO.clear()
+	ppu.fetcher.reset(true)
+	ppu.discard = cut
+	ppu.objTile = -1
+}
+
+// stopWindow vuelve al fondo cuando se desactiva la window a mitad de línea.
+// El fetcher descarta el tile de la window que estaba leyendo y empieza de
+// nuevo con el tile del fondo que corresponde a los píxeles que quedan por
+// entrar en la FIFO.
+func (ppu *PPU) stopWindow() {
+	ppu.windowActive = false
+	x := ppu.lx + ppu.bgFIFO.size + int(ppu.bus.Read(SCXRegister)&0x07)
+	ppu.fetcher.reset(false)
+	ppu.fetcher.tileX = byte(x / 8)
+	ppu.objTile = -1
+}
@@ -0,0 +1,183 @@
+package ppu
+
+import (
+	"testing"
+
+	"github.com/deybismelendez/liteboy/bus"
+)
+
+// newWindowPPU prepara un fondo de color 0 y una window en 9C00 hecha con el
+// tile 1, cuya fila y tiene el color y & 3. Así el tono de un píxel de la
+// window indica la línea de la window que se dibujó.
+func newWindowPPU(wx, wy byte) (*PPU, *bus.Bus) {
+	p, b := newTestPPU()
+	for row := range 8 {
+		if row&1 != 0 {
+			b.VRAM[16+row*2] = 0xFF
+		}
+		if row&2 != 0 {
+			b.VRAM[16+row*2+1] = 0xFF
+		}
+	}
+	for i := range 32 * 32 {
+		b.VRAM[0x1C00+i] = 1
+	}
+	b.Write(LCDCRegister, 0x91|LCDCFlagWindowEnable|LCDCFlagWindowTileMap)
+	b.Write(WXRegister, wx)
+	b.Write(WYRegister, wy)
+	p.SetPalettes(Palettes{})
+	return p, b
+}
+
+// finishLine avanza hasta el final del modo 3 de la línea actual
+func finishLine(p *PPU) {
+	for p.getMode() != ModeVRAM {
+		p.Step(1)
+	}
+	for p.getMode() == ModeVRAM {
+		p.Step(1)
+	}
+}
+
+func setWindowEnabled(b *bus.Bus, enabled bool) {
+	lcdc := b.Read(LCDCRegister) &^ LCDCFlagWindowEnable
+	if enabled {
+		lcdc |= LCDCFlagWindowEnable
+	}
+	b.Write(LCDCRegister, lcdc)
+}
+
+func TestWindowLineCounterSkipsHiddenLines(t *testing.T) {
+	p, b := newWindowPPU(7, 10)
+	runToLine(t, p, b, 11)
+	setWindowEnabled(b, false)
+	runToLine(t, p, b, 20)
+	setWindowEnabled(b, true)
+	finishLine(p)
+
+	if got := p.ShadeAt(0, 10); got != 0 {
+		t.Fatalf("línea 10: tono %d, se esperaba la línea 0 de la window", got)
+	}
+	if got := p.ShadeAt(0, 15); got != 0 || p.LayerAt(0, 15) != LayerBG {
+		t.Fatal("línea 15: la window estaba desactivada")
+	}
+	if got := p.ShadeAt(0, 20); got != 1 {
+		t.Fatalf("línea 20: tono %d, se esperaba la línea 1 de la window", got)
+	}
+}
+
+func TestWindowWYMatchedOncePerFrame(t *testing.T) {
+	// Cambiar WY después de que coincidió no oculta la window
+	p, b := newWindowPPU(7, 10)
+	runToLine(t, p, b, 12)
+	b.Write(WYRegister, 100)
+	finishLine(p)
+	if p.LayerAt(0, 12) != LayerWindow {
+		t.Fatal("la window debería seguir en la línea 12")
+	}
+
+	// Un WY menor que LY no la muestra hasta el frame siguiente
+	p, b = newWindowPPU(7, 100)
+	runToLine(t, p, b, 20)
+	b.Write(WYRegister, 10)
+	finishLine(p)
+	if p.LayerAt(0, 20) != LayerBG {
+		t.Fatal("WY ya pasó en este frame")
+	}
+	runToLine(t, p, b, 10)
+	finishLine(p)
+	if p.LayerAt(0, 10) != LayerWindow {
+		t.Fatal("la window debería aparecer en el frame siguiente")
+	}
+}
+
+func TestWindowDisabledMidLine(t *testing.T) {
+	p, b := newWindowPPU(7, 0)
+	runToLine(t, p, b, 3)
+	// Se desactiva a mitad de la lectura de un tile de la window
+	for p.getMode() != ModeVRAM || p.lx < 80 || p.fetcher.ticks < 3 || p.fetcher.ticks >= fetchHighDots {
+		p.Step(1)
+	}
+	setWindowEnabled(b, false)
+	p.Step(1)
+	if p.fetcher.window || p.fetcher.ticks > 1 {
+		t.Fatalf("el fetcher debería empezar de nuevo con el fondo (window=%v, ticks=%d)",
+			p.fetcher.window, p.fetcher.ticks)
+	}
+	finishLine(p)
+	if p.LayerAt(40, 3) != LayerWindow {
+		t.Fatal("x=40 se dibujó antes de desactivar la window")
+	}
+	if p.LayerAt(120, 3) != LayerBG {
+		t.Fatal("x=120 se dibujó después de desactivar la window")
+	}
+}
+
+func TestWindowWX(t *testing.T) {
+	cases := []struct {
+		name  string
+		wx    byte
+		scx   byte
+		x     int
+		shade byte // Tono de la columna x de la línea 1 (columna 1 del tile)
+	}{
+		{"WX=7", 7, 0, 0, 1},
+		{"WX=3 recorta 4 píxeles", 3, 0, 0, 1},
+		{"WX=100", 100, 0, 93, 1},
+		{"WX=100 fondo", 100, 0, 92, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, b := newWindowPPU(c.wx, 0)
+			b.Write(SCXRegister, c.scx)
+			runToLine(t, p, b, 1)
+			finishLine(p)
+			if got := p.ShadeAt(c.x, 1); got != c.shade {
+				t.Fatalf("x=%d: tono %d, se esperaba %d", c.x, got, c.shade)
+			}
+		})
+	}
+}
+
+// La window con WX=0 se recorta más cuanto mayor es SCX & 7
+func TestWindowWX0Cut(t *testing.T) {
+	for scx := range byte(8) {
+		p, b := newWindowPPU(0, 0)
+		// Tile 2 en la columna 1 del mapa para ver dónde empieza
+		for row := range 8 {
+			b.VRAM[32+row*2+1] = 0xFF
+		}
+		b.VRAM[0x1C01] = 2
+		b.Write(SCXRegister, scx)
+		runToLine(t, p, b, 0)
+		finishLine(p)
+		start := 8 - wx0Cut[scx]
+		if got := p.ShadeAt(max(start, 0), 0); got != 2 {
+			t.Fatalf("SCX=%d: la columna 1 de la window debería empezar en x=%d", scx, start)
+		}
+		if start > 0 && p.ShadeAt(start-1, 0) == 2 {
+			t.Fatalf("SCX=%d: la columna 1 de la window empieza antes de x=%d", scx, start)
+		}
+	}
+}
+
+func TestWindowWX166(t *testing.T) {
+	p, b := newWindowPPU(200, 0)
+	runToLine(t, p, b, 5)
+	b.Write(WXRegister, wx166)
+	finishLine(p)
+	if p.LayerAt(ScreenWidth-1, 5) != LayerBG {
+		t.Fatal("con WX=166 la window no aparece en la línea")
+	}
+	b.Write(WXRegister, 200)
+	runToLine(t, p, b, 6)
+	finishLine(p)
+	if p.LayerAt(0, 6) != LayerWindow || p.LayerAt(ScreenWidth-1, 6) != LayerWindow {
+		t.Fatal("la window debería cubrir toda la línea siguiente")
+	}
+	runToLine(t, p, b, 7)
+	finishLine(p)
+	if p.LayerAt(0, 7) != LayerBG {
+		t.Fatal("el efecto de WX=166 dura una sola línea")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/video"
+)
+
+// recording graba cada frame emulado en un archivo de video. Como avanza con
+// los frames emulados y no con el reloj, una captura en avance rápido se
+// reproduce a velocidad normal.
+type recording struct {
+	path  string
+	video video.Recorder
+	wav   *apu.WAVRecorder // Audio de las grabaciones .y4m
+	scale int
+}
+
+// newRecording crea la grabación según la extensión de path (ver
+// video.CreateRecorder). Los archivos .y4m se acompañan de un WAV con el
+// mismo nombre.
+func newRecording(path string, scale int) (*recording, error) {
+	recorder, err := video.CreateRecorder(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &recording{path: path, video: recorder, scale: scale}
+	if ext := filepath.Ext(path); strings.EqualFold(ext, ".y4m") {
+		r.wav, err = apu.NewWAVRecorder(strings.TrimSuffix(path, ext)+".wav", false)
+		if err != nil {
+			recorder.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// audio devuelve el destino del audio de la grabación, o nil si el formato
+// no guarda audio
+func (r *recording) audio() apu.AudioSink {
+	if r.wav != nil {
+		return r.wav
+	}
+	if sink, ok := r.video.(apu.AudioSink); ok {
+		return sink
+	}
+	return nil
+}
+
+func (r *recording) close() error {
+	err := r.video.Close()
+	if r.wav != nil {
+		err = errors.Join(err, r.wav.Close())
+	}
+	return err
+}
+
+// recordingSink es la salida de audio de la máquina: reenvía las muestras al
+// destino original y, mientras se graba video, también a la grabación
+type recordingSink struct {
+	output    apu.AudioSink
+	recording apu.AudioSink
+}
+
+func (s *recordingSink) WriteSample(left, right int16) {
+	s.output.WriteSample(left, right)
+	if s.recording != nil {
+		s.recording.WriteSample(left, right)
+	}
+}
+
+func (s *recordingSink) WriteChannelSamples(channels [4]int16) {
+	if cs, ok := s.output.(apu.ChannelSink); ok {
+		cs.WriteChannelSamples(channels)
+	}
+}
+
+// startRecording empieza a grabar video desde el próximo frame
+func (m *machine) startRecording(path string, scale int) error {
+	m.stopRecording()
+	r, err := newRecording(path, scale)
+	if err != nil {
+		return err
+	}
+	m.recording = r
+	m.audio.recording = r.audio()
+	log.Println("Grabando video en", path)
+	return nil
+}
+
+func (m *machine) stopRecording() {
+	if m.recording == nil {
+		return
+	}
+	r := m.recording
+	m.recording = nil
+	m.audio.recording = nil
+	if err := r.close(); err != nil {
+		log.Println("error al guardar el video:", err)
+	} else {
+		log.Println("Video guardado en", r.path)
+	}
+}
+
+// recordFrame agrega el último frame a la grabación en curso
+func (m *machine) recordFrame() {
+	if m.recording == nil {
+		return
+	}
+	if err := m.recording.video.WriteFrame(m.video.Screenshot(m.recording.scale)); err != nil {
+		log.Println("error al grabar el video:", err)
+		m.stopRecording()
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd || windows)
+
+package terminal
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("la terminal no está soportada en este sistema")
+
+func MakeRaw(in, out *os.File) (func() error, error) {
+	return nil, errUnsupported
+}
+
+func Size(out *os.File) (columns, rows int, err error) {
+	return 0, 0, errUnsupported
+}
@@ -0,0 +1,43 @@
+package terminal
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// MakeRaw desactiva el eco y el búfer de línea de la consola, y activa las
+// secuencias ANSI en la salida y en la entrada (para leer las flechas).
+// Devuelve la función que restaura los modos anteriores.
+func MakeRaw(in, out *os.File) (func() error, error) {
+	inHandle, outHandle := windows.Handle(in.Fd()), windows.Handle(out.Fd())
+	var inMode, outMode uint32
+	if err := windows.GetConsoleMode(inHandle, &inMode); err != nil {
+		return nil, err
+	}
+	if err := windows.GetConsoleMode(outHandle, &outMode); err != nil {
+		return nil, err
+	}
+	raw := inMode &^ (windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT)
+	raw |= windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(inHandle, raw); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(outHandle, outMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		windows.SetConsoleMode(inHandle, inMode)
+		return nil, err
+	}
+	return func() error {
+		return errors.Join(windows.SetConsoleMode(inHandle, inMode), windows.SetConsoleMode(outHandle, outMode))
+	}, nil
+}
+
+// Size devuelve las columnas y filas visibles de la consola
+func Size(out *os.File) (columns, rows int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(out.Fd()), &info); err != nil {
+		return 0, 0, err
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
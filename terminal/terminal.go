@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Salida y entrada para jugar en una terminal (por ejemplo por SSH). Cada
+// celda muestra dos píxeles con el carácter "▀": el color de frente es el
+// píxel de arriba y el de fondo el de abajo, en color de 24 bits.
+
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l\x1b[2J" // Pantalla alternativa, sin cursor
+	leaveScreen = "\x1b[0m\x1b[?25h\x1b[?1049l"
+	home        = "\x1b[H"
+	clearLine   = "\x1b[0m\x1b[K"
+	upperHalf   = "▀"
+)
+
+// Renderer dibuja frames RGBA en la terminal. Reutiliza el buffer entre
+// frames para escribir cada frame con una sola llamada a Write.
+type Renderer struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+// NewRenderer pasa la terminal a la pantalla alternativa; Close la restaura
+func NewRenderer(w io.Writer) (*Renderer, error) {
+	if _, err := io.WriteString(w, enterScreen); err != nil {
+		return nil, err
+	}
+	return &Renderer{w: w}, nil
+}
+
+func (r *Renderer) Close() error {
+	_, err := io.WriteString(r.w, leaveScreen)
+	return err
+}
+
+// Render dibuja un frame RGBA de width×height ajustado a columns×rows
+// celdas (la última fila queda para status) y escribe status debajo
+func (r *Renderer) Render(frame []byte, width, height, columns, rows int, status string) error {
+	r.buf.Reset()
+	r.buf.WriteString(home)
+
+	// Cada celda cubre step×step píxeles horizontalmente y 2·step verticalmente
+	step := max(1, (width+columns-1)/max(columns, 1), (height+2*(rows-1)-1)/max(2*(rows-1), 1))
+	outWidth := width / step
+	outRows := height / (2 * step)
+
+	var fg, bg [3]byte
+	for row := range outRows {
+		first := true
+		for column := range outWidth {
+			x := column * step
+			top := pixel(frame, width, x, row*2*step)
+			bottom := pixel(frame, width, x, row*2*step+step)
+			if first || top != fg {
+				fmt.Fprintf(&r.buf, "\x1b[38;2;%d;%d;%dm", top[0], top[1], top[2])
+			}
+			if first || bottom != bg {
+				fmt.Fprintf(&r.buf, "\x1b[48;2;%d;%d;%dm", bottom[0], bottom[1], bottom[2])
+			}
+			fg, bg, first = top, bottom, false
+			r.buf.WriteString(upperHalf)
+		}
+		r.buf.WriteString(clearLine + "\r\n")
+	}
+	r.buf.WriteString("\x1b[7m")
+	r.buf.WriteString(truncate(status, columns))
+	r.buf.WriteString(clearLine + "\x1b[J")
+	_, err := r.w.Write(r.buf.Bytes())
+	return err
+}
+
+func pixel(frame []byte, width, x, y int) [3]byte {
+	i := (y*width + x) * 4
+	return [3]byte{frame[i], frame[i+1], frame[i+2]}
+}
+
+// truncate corta s a la cantidad de columnas (contando runas)
+func truncate(s string, columns int) string {
+	if utf8.RuneCountInString(s) <= columns {
+		return s
+	}
+	return string([]rune(s)[:max(columns, 0)])
+}
+
+// Key es una tecla leída de la terminal: una runa o una de las flechas
+type Key rune
+
+const (
+	KeyUp Key = -1 - iota
+	KeyDown
+	KeyRight
+	KeyLeft
+)
+
+const (
+	KeyEnter  Key = '\r'
+	KeyEscape Key = 0x1B
+	KeyCtrlC  Key = 0x03
+)
+
+// ParseKeys interpreta los bytes leídos de la terminal en modo raw. Las
+// flechas llegan como ESC [ A-D (o ESC O A-D en modo aplicación).
+func ParseKeys(data []byte) []Key {
+	var keys []Key
+	for len(data) > 0 {
+		if data[0] == 0x1B && len(data) >= 3 && (data[1] == '[' || data[1] == 'O') {
+			switch data[2] {
+			case 'A':
+				keys = append(keys, KeyUp)
+			case 'B':
+				keys = append(keys, KeyDown)
+			case 'C':
+				keys = append(keys, KeyRight)
+			case 'D':
+				keys = append(keys, KeyLeft)
+			}
+			data = data[3:]
+			continue
+		}
+		r, size := utf8.DecodeRune(data)
+		if r == '\n' {
+			r = '\r'
+		}
+		keys = append(keys, Key(r))
+		data = data[size:]
+	}
+	return keys
+}
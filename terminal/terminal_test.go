@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := ParseKeys([]byte("\x1b[A\x1bOBz\r\n\x03\x1b[D"))
+	want := []Key{KeyUp, KeyDown, 'z', KeyEnter, KeyEnter, KeyCtrlC, KeyLeft}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("teclas %v, se esperaba %v", keys, want)
+	}
+}
+
+// frame de 4x4 con la mitad de arriba roja y la de abajo azul
+func testFrame() []byte {
+	frame := make([]byte, 4*4*4)
+	for i := range 16 {
+		if i < 8 {
+			frame[i*4] = 0xFF
+		} else {
+			frame[i*4+2] = 0xFF
+		}
+		frame[i*4+3] = 0xFF
+	}
+	return frame
+}
+
+func TestRenderHalfBlocks(t *testing.T) {
+	var out bytes.Buffer
+	r := &Renderer{w: &out}
+	if err := r.Render(testFrame(), 4, 4, 80, 24, "TETRIS 60 FPS"); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	if n := strings.Count(text, upperHalf); n != 8 {
+		t.Fatalf("%d celdas, se esperaban 8 (4 columnas × 2 filas)", n)
+	}
+	// Los colores solo se repiten al cambiar
+	if n := strings.Count(text, "\x1b[38;2;255;0;0m"); n != 1 {
+		t.Fatalf("color de frente rojo escrito %d veces", n)
+	}
+	if !strings.Contains(text, "\x1b[38;2;0;0;255m\x1b[48;2;0;0;255m") {
+		t.Fatal("falta la segunda fila azul")
+	}
+	if !strings.Contains(text, "TETRIS 60 FPS") {
+		t.Fatal("falta la línea de estado")
+	}
+}
+
+func TestRenderFitsTheTerminal(t *testing.T) {
+	var out bytes.Buffer
+	r := &Renderer{w: &out}
+	// 2 columnas y 2 filas (una para la imagen): se toma un píxel de cada 2
+	if err := r.Render(testFrame(), 4, 4, 2, 2, "una línea de estado larga"); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), upperHalf); n != 2 {
+		t.Fatalf("%d celdas, se esperaban 2", n)
+	}
+	if !strings.Contains(out.String(), "\x1b[7mun"+clearLine) {
+		t.Fatal("la línea de estado debería cortarse al ancho de la terminal")
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/bus"
+	"github.com/deybismelendez/liteboy/cartridge"
+)
+
+// Estados de un test ROM en el reporte
+const (
+	testPass    = "pass"
+	testFail    = "fail"
+	testTimeout = "timeout"
+	testError   = "error"
+)
+
+// Frames que se sigue emulando después de ver "Passed" o "Failed" en el
+// puerto serie o en pantalla, para que el ROM termine de escribir el resultado
+const testSettleFrames = 10
+
+// Registros B, C, D, E, H, L con los que los tests de Mooneye avisan el
+// resultado al ejecutar LD B,B
+var (
+	passValues = []byte{3, 5, 8, 13, 21, 34}
+	failValues = []byte{0x42, 0x42, 0x42, 0x42, 0x42, 0x42}
+)
+
+// Firma que los tests de Blargg escriben en 0xA001-0xA003 cuando informan el
+// resultado en la RAM del cartucho
+var blarggSignature = []byte{0xDE, 0xB0, 0x61}
+
+// testResult es el resultado de un test ROM
+type testResult struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Detector string  `json:"detector,omitempty"` // serial, mooneye, memory o screen
+	Message  string  `json:"message,omitempty"`
+	Frames   int     `json:"frames"`
+	Seconds  float64 `json:"seconds"`
+}
+
+// testReport es el reporte de una corrida de "liteboy test"
+type testReport struct {
+	Directory string       `json:"directory"`
+	Date      time.Time    `json:"date"`
+	Total     int          `json:"total"`
+	Passed    int          `json:"passed"`
+	Seconds   float64      `json:"seconds"`
+	Results   []testResult `json:"results"`
+}
+
+// runTestCommand implementa "liteboy test <carpeta>": busca los test ROMs de
+// la carpeta, los emula en paralelo y escribe los reportes
+func runTestCommand(args []string) {
+	flags := flag.NewFlagSet("liteboy test", flag.ExitOnError)
+	timeout := flags.Duration("timeout", time.Minute, "tiempo máximo de cada ROM")
+	frames := flags.Int("frames", 6000, "frames emulados como máximo por ROM")
+	jobs := flags.Int("jobs", runtime.NumCPU(), "ROMs que se emulan a la vez")
+	jsonPath := flags.String("json", "test-report.json", "archivo del reporte JSON (vacío para no escribirlo)")
+	junitPath := flags.String("junit", "test-report.xml", "archivo del reporte JUnit XML (vacío para no escribirlo)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: liteboy test <carpeta> [opciones]")
+		flags.PrintDefaults()
+	}
+	positional := parseArgs(flags, args)
+	if len(positional) < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	dir := positional[0]
+
+	roms, err := discoverROMs(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(roms) == 0 {
+		log.Fatalf("no se encontraron ROMs .gb en %s", dir)
+	}
+
+	start := time.Now()
+	report := testReport{Directory: dir, Date: start, Total: len(roms)}
+	report.Results = runROMTests(dir, roms, *jobs, *timeout, *frames)
+	report.Seconds = time.Since(start).Seconds()
+	for _, result := range report.Results {
+		if result.Status == testPass {
+			report.Passed++
+		}
+	}
+	fmt.Printf("%d/%d tests pasaron en %.1fs\n", report.Passed, report.Total, report.Seconds)
+
+	if *jsonPath != "" {
+		if err := writeJSONReport(*jsonPath, report); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *junitPath != "" {
+		if err := writeJUnitReport(*junitPath, report); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if report.Passed != report.Total {
+		os.Exit(1)
+	}
+}
+
+// discoverROMs devuelve los .gb y .gbc de la carpeta y sus subcarpetas en
+// orden alfabético
+func discoverROMs(dir string) ([]string, error) {
+	var roms []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if !entry.IsDir() && (ext == ".gb" || ext == ".gbc") {
+			roms = append(roms, path)
+		}
+		return nil
+	})
+	return roms, err
+}
+
+// runROMTests emula las ROMs con jobs goroutines e imprime cada resultado a
+// medida que termina. Los resultados quedan en el orden de roms.
+func runROMTests(dir string, roms []string, jobs int, timeout time.Duration, frames int) []testResult {
+	results := make([]testResult, len(roms))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var output sync.Mutex
+	for range max(jobs, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				result := runROMTest(roms[i], timeout, frames)
+				if name, err := filepath.Rel(dir, roms[i]); err == nil {
+					result.Name = filepath.ToSlash(name)
+				}
+				results[i] = result
+				output.Lock()
+				fmt.Printf("%-7s %s (%.1fs) %s\n", strings.ToUpper(result.Status), result.Name, result.Seconds, result.Message)
+				output.Unlock()
+			}
+		}()
+	}
+	for i := range roms {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// runROMTest emula un test ROM hasta que informa su resultado, se cumple el
+// timeout o pasan frames frames. El resultado se detecta, en este orden, por
+// los registros de Mooneye al ejecutar LD B,B, la firma de Blargg en
+// 0xA000, el texto enviado por el puerto serie y el texto en pantalla.
+func runROMTest(path string, timeout time.Duration, frames int) (result testResult) {
+	start := time.Now()
+	result = testResult{Name: filepath.ToSlash(path)}
+	defer func() {
+		// La CPU entra en pánico con las instrucciones ilegales; el ROM se
+		// marca como error en vez de detener los demás tests
+		if r := recover(); r != nil {
+			result.Status, result.Detector = testError, ""
+			result.Message = fmt.Sprintf("la emulación se detuvo: %v", r)
+		}
+		result.Seconds = time.Since(start).Seconds()
+	}()
+
+	cart, err := cartridge.LoadCartridge(path)
+	if err != nil {
+		result.Status, result.Message = testError, err.Error()
+		return result
+	}
+	m := newMachine(cart, apu.NullSink{})
+	var serial strings.Builder
+	m.bus.OnSerialTransfer = func(value byte) {
+		serial.WriteByte(value)
+	}
+
+	// Frame en que apareció "Passed" o "Failed" en el texto, -1 si todavía no
+	textFrame := -1
+	for result.Frames = 0; result.Frames < frames; result.Frames++ {
+		for !m.ppu.FrameReady() {
+			opcode := m.cpu.GetOpcode()
+			m.cpu.Step()
+			if opcode != 0x40 {
+				continue
+			}
+			if regs := m.cpu.GetRegisters(); equalBytes(regs, passValues) {
+				result.Status, result.Detector = testPass, "mooneye"
+				return result
+			} else if equalBytes(regs, failValues) {
+				result.Status, result.Detector = testFail, "mooneye"
+				return result
+			}
+		}
+
+		if status, message, ok := blarggMemoryResult(m.bus); ok {
+			result.Status, result.Detector, result.Message = status, "memory", message
+			return result
+		}
+		serialStatus, serialOK := textResult(serial.String())
+		screen := extractScreenText(m.bus)
+		screenStatus, screenOK := textResult(screen)
+		if textFrame < 0 && (serialOK || screenOK) {
+			textFrame = result.Frames
+		}
+		if textFrame >= 0 && result.Frames-textFrame >= testSettleFrames {
+			if serialOK {
+				result.Status, result.Detector = serialStatus, "serial"
+				result.Message = strings.Join(strings.Fields(serial.String()), " ")
+			} else {
+				result.Status, result.Detector = screenStatus, "screen"
+			}
+			return result
+		}
+		if time.Since(start) > timeout {
+			result.Status = testTimeout
+			result.Message = fmt.Sprintf("sin resultado después de %s", timeout)
+			return result
+		}
+	}
+	result.Status = testTimeout
+	result.Message = fmt.Sprintf("sin resultado después de %d frames", frames)
+	return result
+}
+
+// textResult busca "Passed" o "Failed" en el texto que imprime un test ROM
+func textResult(text string) (string, bool) {
+	switch {
+	case strings.Contains(text, "Failed"):
+		return testFail, true
+	case strings.Contains(text, "Passed"):
+		return testPass, true
+	}
+	return "", false
+}
+
+// blarggMemoryResult lee el resultado que los tests de Blargg dejan en la
+// RAM del cartucho: la firma en 0xA001-0xA003, el código en 0xA000 (0x80
+// mientras corre, 0 si pasó) y el texto desde 0xA004 terminado en 0
+func blarggMemoryResult(b *bus.Bus) (status, message string, ok bool) {
+	for i, value := range blarggSignature {
+		if b.Read(0xA001+uint16(i)) != value {
+			return "", "", false
+		}
+	}
+	code := b.Read(0xA000)
+	if code == 0x80 {
+		return "", "", false
+	}
+	var text strings.Builder
+	for addr := uint16(0xA004); addr < 0xC000; addr++ {
+		value := b.Read(addr)
+		if value == 0 {
+			break
+		}
+		text.WriteByte(value)
+	}
+	message = strings.Join(strings.Fields(text.String()), " ")
+	if code != 0 {
+		return testFail, fmt.Sprintf("código %d: %s", code, message), true
+	}
+	return testPass, message, true
+}
+
+// Lee los primeros caracteres del tile map en VRAM para detectar texto
+func extractScreenText(b *bus.Bus) string {
+	vram := b.VRAM
+	base := 0x1800 // VRAM offset para $9800
+	var out strings.Builder
+
+	for i := 0; i < 32*32; i++ { // Lee ~4 filas de 32 tiles
+		if i+base >= len(vram) {
+			break
+		}
+		tile := vram[base+i]
+		// Blargg imprime caracteres ASCII (espacio=0x20)
+		if tile >= 0x20 && tile <= 0x7F {
+			out.WriteByte(tile)
+		} else {
+			out.WriteByte('.') // Placeholder
+		}
+	}
+	return out.String()
+}
+
+func equalBytes(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSONReport(path string, report testReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Estructura del XML de JUnit que entienden los servidores de CI
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Date     string      `xml:"timestamp,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnitReport escribe el reporte como JUnit XML. Los timeouts cuentan
+// como fallos y las ROMs que no se pudieron cargar como errores.
+func writeJUnitReport(path string, report testReport) error {
+	suite := junitSuite{
+		Name:  "liteboy",
+		Tests: report.Total,
+		Time:  fmt.Sprintf("%.3f", report.Seconds),
+		Date:  report.Date.Format("2006-01-02T15:04:05"),
+	}
+	for _, result := range report.Results {
+		// La carpeta de la ROM agrupa los casos, como el paquete en Java
+		c := junitCase{
+			Name:      result.Name,
+			ClassName: suite.Name,
+			Time:      fmt.Sprintf("%.3f", result.Seconds),
+		}
+		if dir := filepath.Dir(filepath.FromSlash(result.Name)); dir != "." {
+			c.ClassName = strings.ReplaceAll(filepath.ToSlash(dir), "/", ".")
+		}
+		problem := &junitProblem{Type: result.Status, Message: result.Message}
+		switch result.Status {
+		case testFail, testTimeout:
+			c.Failure = problem
+			suite.Failures++
+		case testError:
+			c.Error = problem
+			suite.Errors++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0o644)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestROM crea una ROM de 32 KB sin MBC que salta a code en 0x0150
+func writeTestROM(t *testing.T, dir, name string, code []byte) string {
+	t.Helper()
+	rom := make([]byte, 0x8000)
+	copy(rom[0x100:], []byte{0xC3, 0x50, 0x01}) // JP 0x0150
+	copy(rom[0x150:], code)
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Carga B, C, D, E, H y L, ejecuta LD B,B y se queda en un bucle
+func mooneyeCode(regs []byte) []byte {
+	code := []byte{}
+	for i, opcode := range []byte{0x06, 0x0E, 0x16, 0x1E, 0x26, 0x2E} { // LD r,n
+		code = append(code, opcode, regs[i])
+	}
+	return append(code, 0x40, 0x18, 0xFE) // LD B,B; JR -2
+}
+
+// Envía el texto por el puerto serie con reloj interno y se queda en un bucle
+func serialCode(text string) []byte {
+	code := []byte{}
+	for _, c := range []byte(text) {
+		code = append(code, 0x3E, c, 0xE0, 0x01, 0x3E, 0x81, 0xE0, 0x02) // LD A,c; LDH (SB),A; LD A,$81; LDH (SC),A
+	}
+	return append(code, 0x18, 0xFE)
+}
+
+func TestRunROMTest(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		code     []byte
+		status   string
+		detector string
+	}{
+		{"mooneye-pass.gb", mooneyeCode(passValues), testPass, "mooneye"},
+		{"mooneye-fail.gb", mooneyeCode(failValues), testFail, "mooneye"},
+		{"serial-pass.gb", serialCode("cpu_instrs\n\nPassed\n"), testPass, "serial"},
+		{"serial-fail.gb", serialCode("Failed #2\n"), testFail, "serial"},
+		{"loop.gb", []byte{0x18, 0xFE}, testTimeout, ""},
+	}
+	for _, test := range tests {
+		result := runROMTest(writeTestROM(t, dir, test.name, test.code), time.Minute, 30)
+		if result.Status != test.status || result.Detector != test.detector {
+			t.Errorf("%s: estado %q detectado por %q, se esperaba %q por %q (%s)", test.name, result.Status, result.Detector, test.status, test.detector, result.Message)
+		}
+	}
+
+	result := runROMTest(writeTestROM(t, dir, "serial-message.gb", serialCode("Failed #2\n")), time.Minute, 30)
+	if result.Message != "Failed #2" {
+		t.Errorf("mensaje %q, se esperaba el texto del puerto serie", result.Message)
+	}
+}
+
+func TestRunROMTestInvalidROM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short.gb")
+	if err := os.WriteFile(path, []byte{0x00}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if result := runROMTest(path, time.Minute, 30); result.Status != testError || result.Message == "" {
+		t.Errorf("estado %q mensaje %q, se esperaba un error", result.Status, result.Message)
+	}
+}
+
+func TestRunROMTestIllegalOpcode(t *testing.T) {
+	path := writeTestROM(t, t.TempDir(), "illegal.gb", []byte{0xDD})
+	result := runROMTest(path, time.Minute, 30)
+	if result.Status != testError || !strings.Contains(result.Message, "Detenido") {
+		t.Errorf("estado %q mensaje %q, se esperaba un error por el pánico de la CPU", result.Status, result.Message)
+	}
+}
+
+func TestDiscoverROMs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.gb", "a/c.GB", "a/d.gbc", "readme.txt", "a/e.gbs"} {
+		writeTestROM(t, dir, name, nil)
+	}
+	roms, err := discoverROMs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, rom := range roms {
+		name, _ := filepath.Rel(dir, rom)
+		names = append(names, filepath.ToSlash(name))
+	}
+	if got := strings.Join(names, " "); got != "a/c.GB a/d.gbc b.gb" {
+		t.Errorf("ROMs encontradas: %s", got)
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	report := testReport{
+		Total: 4,
+		Results: []testResult{
+			{Name: "cpu_instrs/01-special.gb", Status: testPass},
+			{Name: "cpu_instrs/02-interrupts.gb", Status: testFail, Message: "Failed #2"},
+			{Name: "timer/tim00.gb", Status: testTimeout},
+			{Name: "roto.gb", Status: testError, Message: "ROM demasiado corta"},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "report.xml")
+	if err := writeJUnitReport(path, report); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suite junitSuite
+	if err := xml.Unmarshal(data, &suite); err != nil {
+		t.Fatal(err)
+	}
+	if suite.Tests != 4 || suite.Failures != 2 || suite.Errors != 1 || len(suite.Cases) != 4 {
+		t.Fatalf("tests=%d failures=%d errors=%d casos=%d", suite.Tests, suite.Failures, suite.Errors, len(suite.Cases))
+	}
+	if c := suite.Cases[1]; c.ClassName != "cpu_instrs" || c.Failure == nil || c.Failure.Message != "Failed #2" {
+		t.Errorf("caso con fallo: %+v", c)
+	}
+	if suite.Cases[0].Failure != nil || suite.Cases[3].Error == nil {
+		t.Errorf("casos: %+v", suite.Cases)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/deybismelendez/liteboy/apu"
+	"github.com/deybismelendez/liteboy/cartridge"
+	"github.com/deybismelendez/liteboy/terminal"
+	"github.com/deybismelendez/liteboy/video"
+)
+
+// La terminal no informa cuándo se suelta una tecla: cada pulsación mantiene
+// el botón presionado durante estos frames. Mantener la tecla apretada
+// la repite antes de que se suelte el botón.
+const tuiHoldFrames = 10
+
+// Teclas de cada botón del joypad en la terminal
+var tuiKeys = map[terminal.Key]byte{
+	terminal.KeyRight: buttonRight,
+	terminal.KeyLeft:  buttonLeft,
+	terminal.KeyUp:    buttonUp,
+	terminal.KeyDown:  buttonDown,
+	'z':               buttonA,
+	'x':               buttonB,
+	' ':               buttonSelect,
+	terminal.KeyEnter: buttonStart,
+}
+
+// runTerminal juega la ROM en la terminal, sin audio. Se puede grabar video
+// con --record. El escalador y la grilla se aplican antes de achicar la
+// imagen para la terminal, así que solo se notan si entra más de un
+// carácter por píxel.
+func runTerminal(cart *cartridge.Cartridge, opts frontendOptions) error {
+	m := newMachine(cart, apu.NullSink{})
+	m.ppu.SetPalettes(opts.palettes)
+	m.video.Ghosting = opts.ghosting
+	m.video.Grid = opts.grid
+	m.video.Filter = opts.filter
+	m.video.Scale = opts.scale
+	if opts.recordPath != "" {
+		if err := m.startRecording(opts.recordPath, opts.recordScale); err != nil {
+			return err
+		}
+	}
+	defer m.stopRecording()
+	return runTUI(m)
+}
+
+// runTUI emula el juego en la terminal a la frecuencia del DMG hasta que se
+// presiona Q, Esc o Ctrl+C
+func runTUI(m *machine) error {
+	restore, err := terminal.MakeRaw(os.Stdin, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("no se pudo poner la terminal en modo raw: %w", err)
+	}
+	defer restore()
+	renderer, err := terminal.NewRenderer(os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer renderer.Close()
+
+	keys := make(chan terminal.Key, 64)
+	go readTerminalKeys(keys)
+
+	title := strings.TrimRight(m.cart.Title, "\x00 ")
+	var held [8]int // Frames que le quedan presionado a cada botón
+	fps, frames, second := 0.0, 0, time.Now()
+	frame := float64(time.Second) / video.FrameRate
+	ticker := time.NewTicker(time.Duration(frame))
+	defer ticker.Stop()
+	for range ticker.C {
+		for pending := true; pending; {
+			select {
+			case key := <-keys:
+				switch key {
+				case 'q', 'Q', terminal.KeyEscape, terminal.KeyCtrlC:
+					return nil
+				}
+				if button, ok := tuiKeys[lowerKey(key)]; ok {
+					for i := range held {
+						if button == 1<<i {
+							held[i] = tuiHoldFrames
+						}
+					}
+				}
+			default:
+				pending = false
+			}
+		}
+		var pressed byte
+		for i := range held {
+			if held[i] > 0 {
+				pressed |= 1 << i
+				held[i]--
+			}
+		}
+
+		for !m.ppu.FrameReady() {
+			m.cpu.Step()
+			m.updateJoypad(pressed)
+		}
+		m.endFrame()
+
+		frames++
+		if elapsed := time.Since(second); elapsed >= time.Second {
+			fps = float64(frames) / elapsed.Seconds()
+			frames, second = 0, time.Now()
+		}
+		columns, rows, err := terminal.Size(os.Stdout)
+		if err != nil {
+			columns, rows = video.Width, video.Height/2+1
+		}
+		status := fmt.Sprintf(" %s  FPS: %.1f  Flechas, Z/X, Enter/Espacio  Q: salir", title, fps)
+		img := m.video.Image()
+		if err := renderer.Render(img.Pix, img.Rect.Dx(), img.Rect.Dy(), columns, rows, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lowerKey ignora las mayúsculas para que Z y X funcionen con Bloq Mayús
+func lowerKey(key terminal.Key) terminal.Key {
+	if key >= 'A' && key <= 'Z' {
+		return key + 'a' - 'A'
+	}
+	return key
+}
+
+// readTerminalKeys lee la entrada de la terminal y envía las teclas al canal
+func readTerminalKeys(keys chan<- terminal.Key) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range terminal.ParseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
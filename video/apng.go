@@ -0,0 +1,174 @@
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"os"
+)
+
+// Offset del bloque acTL: firma (8) + IHDR (12 + 13)
+const apngACTLOffset = 33
+
+// APNGWriter graba un PNG animado. Cada frame se comprime con image/png y
+// sus bloques IDAT se copian como IDAT (primer frame) o fdAT. Los visores
+// que no conocen APNG muestran el primer frame.
+type APNGWriter struct {
+	file     *os.File
+	w        *bufio.Writer
+	encoder  png.Encoder
+	size     image.Point
+	frames   int
+	sequence uint32
+}
+
+func CreateAPNG(path string) (*APNGWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &APNGWriter{
+		file:    file,
+		w:       bufio.NewWriter(file),
+		encoder: png.Encoder{CompressionLevel: png.BestSpeed},
+	}, nil
+}
+
+func (a *APNGWriter) WriteFrame(img image.Image) error {
+	if a.frames == 0 {
+		a.size = img.Bounds().Size()
+	}
+	var buf bytes.Buffer
+	if err := a.encoder.Encode(&buf, fixedSize(img, a.size)); err != nil {
+		return err
+	}
+	chunks, err := pngChunks(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if a.frames == 0 {
+		if _, err := a.w.Write(buf.Bytes()[:8]); err != nil {
+			return err
+		}
+		for _, c := range chunks {
+			if c.kind == "IHDR" {
+				if err := a.writeChunk("IHDR", c.data); err != nil {
+					return err
+				}
+			}
+		}
+		if err := a.writeChunk("acTL", a.actl()); err != nil {
+			return err
+		}
+	}
+
+	delay := frameMillis(a.frames+1) - frameMillis(a.frames)
+	fctl := binary.BigEndian.AppendUint32(nil, a.nextSequence())
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(a.size.X))
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(a.size.Y))
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // x
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // y
+	fctl = binary.BigEndian.AppendUint16(fctl, uint16(delay))
+	fctl = binary.BigEndian.AppendUint16(fctl, 1000)
+	fctl = append(fctl, 0, 0) // Sin dispose ni blend: cada frame reemplaza al anterior
+	if err := a.writeChunk("fcTL", fctl); err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if c.kind != "IDAT" {
+			continue
+		}
+		if a.frames == 0 {
+			err = a.writeChunk("IDAT", c.data)
+		} else {
+			err = a.writeChunk("fdAT", append(binary.BigEndian.AppendUint32(nil, a.nextSequence()), c.data...))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	a.frames++
+	return nil
+}
+
+func (a *APNGWriter) nextSequence() uint32 {
+	a.sequence++
+	return a.sequence - 1
+}
+
+// actl devuelve el bloque con la cantidad de frames y repeticiones infinitas
+func (a *APNGWriter) actl() []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(a.frames))
+	return binary.BigEndian.AppendUint32(data, 0)
+}
+
+func (a *APNGWriter) writeChunk(kind string, data []byte) error {
+	return writePNGChunk(a.w, kind, data)
+}
+
+// Close escribe IEND, corrige la cantidad de frames en acTL y cierra el archivo
+func (a *APNGWriter) Close() error {
+	if a.frames == 0 {
+		a.file.Close()
+		return errors.New("la grabación no tiene frames")
+	}
+	err := a.writeChunk("IEND", nil)
+	if err == nil {
+		err = a.w.Flush()
+	}
+	if err == nil {
+		_, err = a.file.Seek(apngACTLOffset, 0)
+	}
+	if err == nil {
+		a.w.Reset(a.file)
+		err = a.writeChunk("acTL", a.actl())
+	}
+	if err == nil {
+		err = a.w.Flush()
+	}
+	return errors.Join(err, a.file.Close())
+}
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// pngChunks separa los bloques de un PNG
+func pngChunks(data []byte) ([]pngChunk, error) {
+	if len(data) < 8 {
+		return nil, errors.New("PNG inválido")
+	}
+	var chunks []pngChunk
+	for i := 8; i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		if i+12+length > len(data) {
+			return nil, fmt.Errorf("bloque PNG truncado en %d", i)
+		}
+		chunks = append(chunks, pngChunk{kind: string(data[i+4 : i+8]), data: data[i+8 : i+8+length]})
+		i += 12 + length
+	}
+	return chunks, nil
+}
+
+func writePNGChunk(w *bufio.Writer, kind string, data []byte) error {
+	header := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	header = append(header, kind...)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err := w.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+	return err
+}
@@ -0,0 +1,268 @@
+package video
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"os"
+
+	"github.com/deybismelendez/liteboy/apu"
+)
+
+// Tamaño de la cabecera del AVI hasta los datos del bloque LIST movi
+const aviHeaderSize = 12 + // RIFF AVI
+	12 + // LIST hdrl
+	8 + 56 + // avih
+	12 + 8 + 56 + 8 + 40 + // LIST strl de video: strh y strf (BITMAPINFOHEADER)
+	12 + 8 + 56 + 8 + 16 + // LIST strl de audio: strh y strf (PCMWAVEFORMAT)
+	12 // LIST movi
+
+// Un AVI 1.0 usa tamaños de 32 bits; se corta antes de 2 GB porque muchos
+// reproductores los leen con signo
+const aviMaxSize = 1<<31 - 1<<20
+
+const (
+	aviHasIndex      = 0x10
+	aviIsInterleaved = 0x100
+	aviKeyFrame      = 0x10
+	aviAudioBlock    = 4 // Muestra estéreo de 16 bits
+)
+
+type aviIndexEntry struct {
+	id     string
+	offset uint32 // Desde el identificador "movi"
+	size   uint32
+}
+
+// AVIWriter graba un AVI con video RGB de 24 bits sin comprimir y audio PCM
+// estéreo de 16 bits intercalado. Es un apu.AudioSink: las muestras que
+// recibe entre dos frames se escriben antes del frame siguiente.
+type AVIWriter struct {
+	file    *os.File
+	w       *bufio.Writer
+	size    image.Point
+	frames  int
+	samples int
+	audio   []byte
+	moviEnd uint32 // Bytes escritos dentro de LIST movi, contando "movi"
+	index   []aviIndexEntry
+	row     []byte
+	err     error
+}
+
+func CreateAVI(path string) (*AVIWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	a := &AVIWriter{file: file, w: bufio.NewWriter(file), moviEnd: 4}
+	// La cabecera se reescribe al cerrar con los tamaños finales
+	if err := a.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *AVIWriter) WriteSample(left, right int16) {
+	a.audio = binary.LittleEndian.AppendUint16(a.audio, uint16(left))
+	a.audio = binary.LittleEndian.AppendUint16(a.audio, uint16(right))
+}
+
+func (a *AVIWriter) WriteFrame(img image.Image) error {
+	if a.err != nil {
+		return a.err
+	}
+	if a.frames == 0 {
+		a.size = img.Bounds().Size()
+		a.row = make([]byte, (a.size.X*3+3)&^3)
+	}
+	if err := a.flushAudio(); err != nil {
+		return err
+	}
+	rgba := fixedSize(img, a.size)
+	frameSize := len(a.row) * a.size.Y
+	if err := a.startChunk("00db", frameSize); err != nil {
+		return err
+	}
+	// DIB de abajo hacia arriba en orden BGR
+	for y := a.size.Y - 1; y >= 0; y-- {
+		for x := range a.size.X {
+			p := rgba.Pix[rgba.PixOffset(x, y):]
+			a.row[x*3], a.row[x*3+1], a.row[x*3+2] = p[2], p[1], p[0]
+		}
+		if _, err := a.w.Write(a.row); err != nil {
+			return a.fail(err)
+		}
+	}
+	a.frames++
+	return nil
+}
+
+func (a *AVIWriter) flushAudio() error {
+	if len(a.audio) == 0 {
+		return nil
+	}
+	if err := a.startChunk("01wb", len(a.audio)); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(a.audio); err != nil {
+		return a.fail(err)
+	}
+	a.samples += len(a.audio) / aviAudioBlock
+	a.audio = a.audio[:0]
+	return nil
+}
+
+// startChunk escribe la cabecera de un bloque de movi y lo agrega al índice
+func (a *AVIWriter) startChunk(id string, size int) error {
+	total := int64(aviHeaderSize) + int64(a.moviEnd) + 8 + int64(size) + int64(len(a.index)+1)*16
+	if total > aviMaxSize {
+		return a.fail(errors.New("el AVI llegó al tamaño máximo de 2 GB; para capturas largas usar .y4m"))
+	}
+	a.index = append(a.index, aviIndexEntry{id: id, offset: a.moviEnd, size: uint32(size)})
+	header := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(size))...)
+	if _, err := a.w.Write(header); err != nil {
+		return a.fail(err)
+	}
+	a.moviEnd += 8 + uint32(size)
+	return nil
+}
+
+func (a *AVIWriter) fail(err error) error {
+	if a.err == nil {
+		a.err = err
+	}
+	return err
+}
+
+// Close escribe el índice, reescribe la cabecera y cierra el archivo
+func (a *AVIWriter) Close() error {
+	err := a.err
+	if err == nil {
+		err = a.flushAudio()
+	}
+	if err == nil {
+		err = a.writeIndex()
+	}
+	if err == nil {
+		err = a.w.Flush()
+	}
+	if err == nil {
+		_, err = a.file.Seek(0, 0)
+	}
+	if err == nil {
+		a.w.Reset(a.file)
+		err = a.writeHeader()
+	}
+	if err == nil {
+		err = a.w.Flush()
+	}
+	if err == nil && a.frames == 0 {
+		err = errors.New("la grabación no tiene frames")
+	}
+	return errors.Join(err, a.file.Close())
+}
+
+func (a *AVIWriter) writeIndex() error {
+	data := append([]byte("idx1"), binary.LittleEndian.AppendUint32(nil, uint32(len(a.index)*16))...)
+	for _, entry := range a.index {
+		data = append(data, entry.id...)
+		data = binary.LittleEndian.AppendUint32(data, aviKeyFrame)
+		data = binary.LittleEndian.AppendUint32(data, entry.offset)
+		data = binary.LittleEndian.AppendUint32(data, entry.size)
+	}
+	_, err := a.w.Write(data)
+	return err
+}
+
+func (a *AVIWriter) writeHeader() error {
+	le := binary.LittleEndian
+	frameSize := uint32(len(a.row) * a.size.Y)
+	width, height := uint32(a.size.X), uint32(a.size.Y)
+	audioRate := uint32(apu.SampleRate * aviAudioBlock)
+	riffSize := uint32(aviHeaderSize) - 8 + a.moviEnd - 4 + 8 + uint32(len(a.index)*16)
+
+	h := []byte("RIFF")
+	h = le.AppendUint32(h, riffSize)
+	h = append(h, "AVI LIST"...)
+	h = le.AppendUint32(h, aviHeaderSize-12-12-8) // hdrl sin el LIST movi
+	h = append(h, "hdrlavih"...)
+	h = le.AppendUint32(h, 56)
+	h = le.AppendUint32(h, uint32(int64(FrameCycles)*1000000/ClockRate)) // µs por frame
+	h = le.AppendUint32(h, uint32(float64(frameSize)*FrameRate)+audioRate)
+	h = le.AppendUint32(h, 0) // Granularidad de relleno
+	h = le.AppendUint32(h, aviHasIndex|aviIsInterleaved)
+	h = le.AppendUint32(h, uint32(a.frames))
+	h = le.AppendUint32(h, 0) // Frames iniciales
+	h = le.AppendUint32(h, 2) // Streams
+	h = le.AppendUint32(h, frameSize)
+	h = le.AppendUint32(h, width)
+	h = le.AppendUint32(h, height)
+	h = append(h, make([]byte, 16)...)
+
+	// Stream de video
+	h = append(h, "LIST"...)
+	h = le.AppendUint32(h, 4+8+56+8+40)
+	h = append(h, "strlstrh"...)
+	h = le.AppendUint32(h, 56)
+	h = append(h, "vidsDIB "...)
+	h = le.AppendUint32(h, 0) // Flags
+	h = le.AppendUint32(h, 0) // Prioridad e idioma
+	h = le.AppendUint32(h, 0) // Frames iniciales
+	h = le.AppendUint32(h, FrameCycles)
+	h = le.AppendUint32(h, ClockRate)
+	h = le.AppendUint32(h, 0) // Inicio
+	h = le.AppendUint32(h, uint32(a.frames))
+	h = le.AppendUint32(h, frameSize)
+	h = le.AppendUint32(h, 0xFFFFFFFF) // Calidad por defecto
+	h = le.AppendUint32(h, 0)          // Tamaño de muestra variable
+	h = le.AppendUint16(h, 0)
+	h = le.AppendUint16(h, 0)
+	h = le.AppendUint16(h, uint16(width))
+	h = le.AppendUint16(h, uint16(height))
+	h = append(h, "strf"...)
+	h = le.AppendUint32(h, 40)
+	h = le.AppendUint32(h, 40)
+	h = le.AppendUint32(h, width)
+	h = le.AppendUint32(h, height) // Positivo: de abajo hacia arriba
+	h = le.AppendUint16(h, 1)      // Planos
+	h = le.AppendUint16(h, 24)     // Bits por píxel
+	h = le.AppendUint32(h, 0)      // BI_RGB
+	h = le.AppendUint32(h, frameSize)
+	h = append(h, make([]byte, 16)...)
+
+	// Stream de audio
+	h = append(h, "LIST"...)
+	h = le.AppendUint32(h, 4+8+56+8+16)
+	h = append(h, "strlstrh"...)
+	h = le.AppendUint32(h, 56)
+	h = append(h, "auds"...)
+	h = le.AppendUint32(h, 0)
+	h = le.AppendUint32(h, 0)
+	h = le.AppendUint32(h, 0)
+	h = le.AppendUint32(h, 0)
+	h = le.AppendUint32(h, aviAudioBlock)
+	h = le.AppendUint32(h, audioRate)
+	h = le.AppendUint32(h, 0)
+	h = le.AppendUint32(h, uint32(a.samples))
+	h = le.AppendUint32(h, audioRate/10)
+	h = le.AppendUint32(h, 0xFFFFFFFF)
+	h = le.AppendUint32(h, aviAudioBlock)
+	h = append(h, make([]byte, 8)...)
+	h = append(h, "strf"...)
+	h = le.AppendUint32(h, 16)
+	h = le.AppendUint16(h, 1) // PCM
+	h = le.AppendUint16(h, 2) // Estéreo
+	h = le.AppendUint32(h, apu.SampleRate)
+	h = le.AppendUint32(h, audioRate)
+	h = le.AppendUint16(h, aviAudioBlock)
+	h = le.AppendUint16(h, 16)
+
+	h = append(h, "LIST"...)
+	h = le.AppendUint32(h, a.moviEnd)
+	h = append(h, "movi"...)
+	_, err := a.w.Write(h)
+	return err
+}
@@ -0,0 +1,95 @@
+package video
+
+import (
+	"image"
+	"image/color"
+)
+
+// Filter es un escalador de pixel art que multiplica el tamaño de la imagen
+// por Scale
+type Filter struct {
+	Name  string
+	Scale int
+	apply func(src, dst *image.RGBA)
+}
+
+// Escaladores incluidos, en el orden en que se recorren
+var Filters = []Filter{
+	{"none", 1, nil},
+	{"scale2x", 2, scale2x},
+	{"scale3x", 3, scale3x},
+	{"hq2x", 2, hq2x},
+	{"smooth2x", 2, smooth2x},
+	{"xbr", 2, xbr2x},
+}
+
+// LookupFilter busca un escalador por nombre
+func LookupFilter(name string) (Filter, bool) {
+	for _, f := range Filters {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
+
+// FilterNames devuelve los nombres de los escaladores incluidos
+func FilterNames() []string {
+	names := make([]string, len(Filters))
+	for i, f := range Filters {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Apply escala src con el filtro. dst se reutiliza si tiene el tamaño correcto.
+func (f Filter) Apply(src, dst *image.RGBA) *image.RGBA {
+	if f.apply == nil {
+		return src
+	}
+	dst = reuse(dst, src.Rect.Dx()*f.Scale, src.Rect.Dy()*f.Scale)
+	f.apply(src, dst)
+	return dst
+}
+
+// at devuelve el píxel (x, y) repitiendo los bordes de la imagen
+func at(img *image.RGBA, x, y int) color.RGBA {
+	x = min(max(x, 0), img.Rect.Dx()-1)
+	y = min(max(y, 0), img.Rect.Dy()-1)
+	return img.RGBAAt(x, y)
+}
+
+// blend mezcla colores con los pesos indicados
+func blend(colors []color.RGBA, weights []int) color.RGBA {
+	var r, g, b, a, total int
+	for i, c := range colors {
+		w := weights[i]
+		r += int(c.R) * w
+		g += int(c.G) * w
+		b += int(c.B) * w
+		a += int(c.A) * w
+		total += w
+	}
+	return color.RGBA{
+		R: uint8((r + total/2) / total),
+		G: uint8((g + total/2) / total),
+		B: uint8((b + total/2) / total),
+		A: uint8((a + total/2) / total),
+	}
+}
+
+// yuv convierte un color para compararlo como lo hacen HQ2x y xBR
+func yuv(c color.RGBA) (y, u, v int) {
+	r, g, b := int(c.R), int(c.G), int(c.B)
+	y = (299*r + 587*g + 114*b) / 1000
+	u = (-169*r - 331*g + 500*b) / 1000
+	v = (500*r - 419*g - 81*b) / 1000
+	return y, u, v
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,149 @@
+package video
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var (
+	black = color.RGBA{A: 0xFF}
+	white = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+)
+
+// diagonalImage dibuja una escalera negra (x <= y) sobre fondo blanco
+func diagonalImage(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			c := white
+			if x <= y {
+				c = black
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestFiltersKeepFlatImages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+	for _, filter := range Filters {
+		dst := filter.Apply(src, nil)
+		if dst.Rect.Dx() != 8*filter.Scale || dst.Rect.Dy() != 8*filter.Scale {
+			t.Fatalf("%s: tamaño %v", filter.Name, dst.Rect)
+		}
+		for i, value := range dst.Pix {
+			if value != 0x80 {
+				t.Fatalf("%s: el byte %d cambió a %02X", filter.Name, i, value)
+			}
+		}
+	}
+}
+
+func TestScale2xSmoothsDiagonals(t *testing.T) {
+	dst := Filters[1].Apply(diagonalImage(4), nil)
+	// El píxel blanco (2, 1) está sobre la diagonal: su esquina inferior
+	// izquierda toma el negro de los vecinos de abajo y de la izquierda
+	if dst.RGBAAt(4, 3) != black {
+		t.Fatal("la esquina sobre la diagonal debería ser negra")
+	}
+	if dst.RGBAAt(5, 2) != white {
+		t.Fatal("la esquina opuesta debería seguir blanca")
+	}
+}
+
+func TestScale3xSmoothsDiagonals(t *testing.T) {
+	dst := Filters[2].Apply(diagonalImage(4), nil)
+	if dst.RGBAAt(6, 5) != black || dst.RGBAAt(7, 4) != white || dst.RGBAAt(8, 3) != white {
+		t.Fatal("Scale3x debería rellenar solo la esquina sobre la diagonal")
+	}
+}
+
+func TestSmoothingFiltersBlendEdges(t *testing.T) {
+	for _, name := range []string{"hq2x", "smooth2x", "xbr"} {
+		filter, _ := LookupFilter(name)
+		dst := filter.Apply(diagonalImage(6), nil)
+		// Algún subpíxel sobre la diagonal debería tener un gris intermedio
+		found := false
+		for y := range dst.Rect.Dy() {
+			for x := range dst.Rect.Dx() {
+				if c := dst.RGBAAt(x, y); c != black && c != white {
+					found = true
+				}
+			}
+		}
+		if !found {
+			t.Fatalf("%s no suavizó la diagonal", name)
+		}
+		if dst.RGBAAt(0, 11) != black || dst.RGBAAt(11, 0) != white {
+			t.Fatalf("%s cambió píxeles lejos del borde", name)
+		}
+	}
+}
+
+func TestHQ2xIsolatedPixel(t *testing.T) {
+	// Con los 8 vecinos distintos (patrón 255) y parecidos entre sí, cada
+	// subpíxel mezcla 14:1:1 el centro con los dos lados
+	src := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	for y := range 3 {
+		for x := range 3 {
+			src.SetRGBA(x, y, white)
+		}
+	}
+	src.SetRGBA(1, 1, black)
+	filter, _ := LookupFilter("hq2x")
+	dst := filter.Apply(src, nil)
+	for y := range 6 {
+		for x := range 6 {
+			want := white
+			if x/2 == 1 && y/2 == 1 {
+				want = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xFF}
+			}
+			if got := dst.RGBAAt(x, y); got != want {
+				t.Fatalf("subpíxel (%d, %d) = %v, se esperaba %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestXBRShallowEdge(t *testing.T) {
+	// Escalera negra de pendiente 1/2 (x <= 2y): en un borde más horizontal
+	// que 45 grados la esquina toma 3/4 del vecino y el subpíxel de al lado 1/4
+	src := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for y := range 6 {
+		for x := range 8 {
+			c := white
+			if x <= 2*y {
+				c = black
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	filter, _ := LookupFilter("xbr")
+	dst := filter.Apply(src, nil)
+	if got := dst.RGBAAt(6, 3).R; got != 0x40 {
+		t.Fatalf("esquina = %02X, se esperaba 40", got)
+	}
+	if got := dst.RGBAAt(7, 3).R; got != 0xBF {
+		t.Fatalf("subpíxel de al lado = %02X, se esperaba BF", got)
+	}
+}
+
+func TestPipelineFilterAndGrid(t *testing.T) {
+	p := NewPipeline()
+	p.Filter = "scale2x"
+	p.Push(solidFrame(0xC8))
+	if img := p.Image(); img.Rect.Dx() != Width*2 {
+		t.Fatalf("tamaño %v", img.Rect)
+	}
+	p.Grid = true
+	p.Scale = 4
+	img := p.Image()
+	if img.Rect.Dx() != Width*4 || img.RGBAAt(3, 0).R != 0x96 || img.RGBAAt(1, 0).R != 0xC8 {
+		t.Fatal("la grilla debería marcar celdas de 4×4 sobre la imagen escalada")
+	}
+}
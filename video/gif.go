@@ -0,0 +1,121 @@
+package video
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+)
+
+// Demora mínima entre frames de un GIF en centésimas. Muchos visores
+// muestran más lento los frames de 1 centésima, así que se graba a ~30 fps
+// descartando los frames que llegarían antes.
+const gifMinDelay = 2
+
+// GIFWriter graba un GIF animado. Los frames se guardan en memoria y se
+// escriben al cerrar, así que solo sirve para clips cortos. Si el frame
+// tiene hasta 256 colores (lo normal sin ghosting ni escaladores) se usa
+// una paleta exacta; si no, la paleta Plan 9 sin tramado.
+type GIFWriter struct {
+	path   string
+	size   image.Point
+	frames int // Frames emulados recibidos
+	images []*image.Paletted
+	starts []int // Instante de cada imagen en centésimas
+}
+
+func CreateGIF(path string) (*GIFWriter, error) {
+	// Se crea el archivo ahora para informar los errores al empezar
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	return &GIFWriter{path: path}, nil
+}
+
+func (g *GIFWriter) WriteFrame(img image.Image) error {
+	n := g.frames
+	g.frames++
+	if n == 0 {
+		g.size = img.Bounds().Size()
+	}
+	start := frameMillis(n) / 10
+	if len(g.starts) > 0 && start-g.starts[len(g.starts)-1] < gifMinDelay {
+		return nil
+	}
+	frame := paletted(fixedSize(img, g.size))
+	if len(g.images) > 0 {
+		last := g.images[len(g.images)-1]
+		if bytes.Equal(last.Pix, frame.Pix) && samePalette(last.Palette, frame.Palette) {
+			return nil // Se alarga la demora del frame anterior
+		}
+	}
+	g.images = append(g.images, frame)
+	g.starts = append(g.starts, start)
+	return nil
+}
+
+func (g *GIFWriter) Close() error {
+	if len(g.images) == 0 {
+		return errors.New("la grabación no tiene frames")
+	}
+	anim := &gif.GIF{Image: g.images, Delay: make([]int, len(g.images))}
+	end := frameMillis(g.frames) / 10
+	for i := range g.images {
+		next := end
+		if i+1 < len(g.starts) {
+			next = g.starts[i+1]
+		}
+		anim.Delay[i] = max(next-g.starts[i], gifMinDelay)
+	}
+	file, err := os.Create(g.path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(file, anim); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func paletted(img *image.RGBA) *image.Paletted {
+	var colors color.Palette
+	index := map[color.RGBA]uint8{}
+	for i := 0; i < len(img.Pix); i += 4 {
+		c := color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3]}
+		if _, ok := index[c]; ok {
+			continue
+		}
+		if len(colors) == 256 {
+			out := image.NewPaletted(img.Rect, palette.Plan9)
+			draw.Draw(out, img.Rect, img, image.Point{}, draw.Src)
+			return out
+		}
+		index[c] = uint8(len(colors))
+		colors = append(colors, c)
+	}
+	out := image.NewPaletted(img.Rect, colors)
+	for i := range out.Pix {
+		p := img.Pix[i*4 : i*4+4]
+		out.Pix[i] = index[color.RGBA{p[0], p[1], p[2], p[3]}]
+	}
+	return out
+}
+
+func samePalette(a, b color.Palette) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package video
+
+import (
+	"image"
+	"image/color"
+)
+
+// HQ2x (Maxim Stepin). Cada píxel E se compara en YUV con sus 8 vecinos
+//
+//	A B C
+//	D E F
+//	G H I
+//
+// y los que se ven distintos forman un patrón de 8 bits (A es el bit 0, B el
+// 1, C el 2, D el 3, F el 4, G el 5, H el 6 e I el 7). El patrón elige en
+// hqTable la regla con la que se mezcla el subpíxel superior izquierdo; los
+// otros tres usan la misma tabla rotando el vecindario. Es la tabla de 256
+// casos del hq2x original reducida a una esquina, como la de bsnes.
+
+// Diferencias máximas en Y, U y V para considerar que dos colores se parecen
+const (
+	hqThresholdY = 48
+	hqThresholdU = 7
+	hqThresholdV = 6
+)
+
+// hqDiff indica si dos colores se ven distintos según los umbrales de HQ2x
+func hqDiff(a, b color.RGBA) bool {
+	if a == b {
+		return false
+	}
+	ya, ua, va := yuv(a)
+	yb, ub, vb := yuv(b)
+	return abs(ya-yb) > hqThresholdY || abs(ua-ub) > hqThresholdU || abs(va-vb) > hqThresholdV
+}
+
+// Regla del subpíxel superior izquierdo para cada patrón (ver hqBlend)
+var hqTable = [256]byte{
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 15, 12, 5, 3, 17, 13,
+	4, 4, 6, 18, 4, 4, 6, 18, 5, 3, 12, 12, 5, 3, 1, 12,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 17, 13, 5, 3, 16, 14,
+	4, 4, 6, 18, 4, 4, 6, 18, 5, 3, 16, 12, 5, 3, 1, 14,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 19, 12, 12, 5, 19, 16, 12,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 16, 12, 5, 3, 16, 12,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 19, 1, 12, 5, 19, 1, 14,
+	4, 4, 6, 2, 4, 4, 6, 18, 5, 3, 16, 12, 5, 19, 1, 14,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 15, 12, 5, 3, 17, 13,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 16, 12, 5, 3, 16, 12,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 17, 13, 5, 3, 16, 14,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 16, 13, 5, 3, 1, 14,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 16, 12, 5, 3, 16, 13,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 16, 12, 5, 3, 1, 12,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 16, 12, 5, 3, 1, 14,
+	4, 4, 6, 2, 4, 4, 6, 2, 5, 3, 1, 12, 5, 3, 1, 14,
+}
+
+// Posición (en el vecindario de 3×3, de izquierda a derecha y de arriba
+// abajo) de A, B, C, D, F, G, H e I para cada subpíxel. Cada esquina es la
+// anterior rotada 90 grados en sentido horario.
+var hqCorners = [4]struct {
+	x, y      int
+	neighbors [8]int
+}{
+	{0, 0, [8]int{0, 1, 2, 3, 5, 6, 7, 8}},
+	{1, 0, [8]int{2, 5, 8, 1, 7, 0, 3, 6}},
+	{1, 1, [8]int{8, 7, 6, 5, 3, 2, 1, 0}},
+	{0, 1, [8]int{6, 3, 0, 7, 1, 8, 5, 2}},
+}
+
+func hq2x(src, dst *image.RGBA) {
+	var w [9]color.RGBA
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			for i := range w {
+				w[i] = at(src, x+i%3-1, y+i/3-1)
+			}
+			e := w[4]
+			for _, corner := range hqCorners {
+				n := corner.neighbors
+				var pattern byte
+				for bit, i := range n {
+					if hqDiff(e, w[i]) {
+						pattern |= 1 << bit
+					}
+				}
+				c := hqBlend(hqTable[pattern], e, w[n[0]], w[n[1]], w[n[3]], w[n[4]], w[n[6]])
+				dst.SetRGBA(x*2+corner.x, y*2+corner.y, c)
+			}
+		}
+	}
+}
+
+// hqBlend mezcla el subpíxel superior izquierdo de e según la regla. Las
+// reglas 12 a 19 dependen de si los lados que forman la esquina se parecen.
+func hqBlend(rule byte, e, a, b, d, f, h color.RGBA) color.RGBA {
+	switch rule {
+	case 1:
+		return blend([]color.RGBA{e, a}, []int{3, 1})
+	case 2:
+		return blend([]color.RGBA{e, d}, []int{3, 1})
+	case 3:
+		return blend([]color.RGBA{e, b}, []int{3, 1})
+	case 4:
+		return blend([]color.RGBA{e, d, b}, []int{2, 1, 1})
+	case 5:
+		return blend([]color.RGBA{e, a, b}, []int{2, 1, 1})
+	case 6:
+		return blend([]color.RGBA{e, a, d}, []int{2, 1, 1})
+	case 12, 13, 14:
+		if hqDiff(b, d) {
+			return e
+		}
+		return hqCornerBlend(rule, e, b, d)
+	case 15, 16, 17:
+		if hqDiff(b, d) {
+			return blend([]color.RGBA{e, a}, []int{3, 1})
+		}
+		return hqCornerBlend(rule, e, b, d)
+	case 18:
+		if hqDiff(b, f) {
+			return blend([]color.RGBA{e, d}, []int{3, 1})
+		}
+		return blend([]color.RGBA{e, b, d}, []int{5, 2, 1})
+	case 19:
+		if hqDiff(d, h) {
+			return blend([]color.RGBA{e, b}, []int{3, 1})
+		}
+		return blend([]color.RGBA{e, d, b}, []int{5, 2, 1})
+	}
+	return e
+}
+
+// hqCornerBlend es la mezcla de las reglas 12 a 17 cuando B y D se parecen,
+// es decir cuando un borde cruza la esquina
+func hqCornerBlend(rule byte, e, b, d color.RGBA) color.RGBA {
+	switch rule {
+	case 12, 15:
+		return blend([]color.RGBA{e, d, b}, []int{2, 1, 1})
+	case 16:
+		return blend([]color.RGBA{e, d, b}, []int{6, 1, 1})
+	case 13, 17:
+		return blend([]color.RGBA{e, d, b}, []int{2, 3, 3})
+	}
+	return blend([]color.RGBA{e, d, b}, []int{14, 1, 1})
+}
@@ -0,0 +1,70 @@
+package video
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+)
+
+// Los frames se graban uno por cada frame emulado y los archivos indican la
+// frecuencia real del DMG (4194304 / 70224 ≈ 59,73 Hz), así que una captura
+// hecha en avance rápido se reproduce a velocidad normal.
+const (
+	ClockRate   = 4194304
+	FrameCycles = 70224
+	FrameRate   = float64(ClockRate) / FrameCycles
+)
+
+// Recorder guarda una secuencia de frames en un archivo
+type Recorder interface {
+	WriteFrame(img image.Image) error
+	Close() error
+}
+
+// Formatos de grabación según la extensión del archivo
+var RecordFormats = []string{"apng", "gif", "y4m", "avi"}
+
+// CreateRecorder crea la grabación según la extensión de path: .png o .apng
+// (PNG animado), .gif, .y4m (video sin audio, se acompaña con un WAV) o
+// .avi (video RGB sin comprimir con audio PCM). Los formatos animados
+// sirven para clips cortos; Y4M y AVI para capturas largas.
+func CreateRecorder(path string) (Recorder, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".apng":
+		return CreateAPNG(path)
+	case ".gif":
+		return CreateGIF(path)
+	case ".y4m":
+		return CreateY4M(path)
+	case ".avi":
+		return CreateAVI(path)
+	}
+	return nil, fmt.Errorf("%s: formato de video desconocido, se esperaba .png, .apng, .gif, .y4m o .avi", path)
+}
+
+// frameMillis devuelve el instante en milisegundos en que empieza el frame n.
+// Las demoras de los formatos animados se calculan como diferencias entre
+// estos instantes para que el redondeo no se acumule.
+func frameMillis(n int) int {
+	return int((int64(n)*FrameCycles*1000 + ClockRate/2) / ClockRate)
+}
+
+// fixedSize convierte el frame a RGBA del tamaño de la grabación (el del
+// primer frame), por si cambió el escalador a mitad de la captura
+func fixedSize(img image.Image, size image.Point) *image.RGBA {
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Rect.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rectangle{Max: img.Bounds().Size()})
+		bounds := img.Bounds()
+		for y := range bounds.Dy() {
+			for x := range bounds.Dx() {
+				rgba.Set(x, y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+			}
+		}
+	}
+	if rgba.Rect.Size() != size {
+		rgba = resizeNearest(rgba, size.X, size.Y)
+	}
+	return rgba
+}
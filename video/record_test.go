@@ -0,0 +1,156 @@
+package video
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordFrames graba n frames que alternan entre dos tonos
+func recordFrames(t *testing.T, path string, n int) []byte {
+	t.Helper()
+	recorder, err := CreateRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPipeline()
+	for i := range n {
+		p.Push(solidFrame(byte(0x40 + i%2*0x80)))
+		if avi, ok := recorder.(*AVIWriter); ok {
+			for range 735 {
+				avi.WriteSample(1, -1)
+			}
+		}
+		if err := recorder.WriteFrame(p.Image()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFrameMillisFollowsTheDMGClock(t *testing.T) {
+	// 65536 frames de 70224 ciclos a 4194304 Hz duran exactamente 1097,25 s
+	if got := frameMillis(65536); got != 1097250 {
+		t.Fatalf("65536 frames = %d ms, se esperaban 1097250", got)
+	}
+	if got := frameMillis(1); got != 17 {
+		t.Fatalf("un frame = %d ms", got)
+	}
+}
+
+func TestAPNG(t *testing.T) {
+	data := recordFrames(t, filepath.Join(t.TempDir(), "clip.png"), 10)
+	chunks, err := pngChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, c := range chunks {
+		counts[c.kind]++
+		if c.kind == "acTL" && binary.BigEndian.Uint32(c.data) != 10 {
+			t.Fatalf("acTL indica %d frames", binary.BigEndian.Uint32(c.data))
+		}
+	}
+	if counts["acTL"] != 1 || counts["fcTL"] != 10 || counts["fdAT"] < 9 || counts["IEND"] != 1 {
+		t.Fatalf("bloques: %v", counts)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != Width {
+		t.Fatalf("tamaño %v", img.Bounds())
+	}
+}
+
+func TestGIFDropsFramesToKeepTiming(t *testing.T) {
+	data := recordFrames(t, filepath.Join(t.TempDir(), "clip.gif"), 60)
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, delay := range anim.Delay {
+		if delay < gifMinDelay {
+			t.Fatalf("demora de %d centésimas", delay)
+		}
+		total += delay
+	}
+	if total < 99 || total > 102 {
+		t.Fatalf("60 frames duran %d centésimas, se esperaba ~1 s", total)
+	}
+}
+
+func TestY4M(t *testing.T) {
+	data := recordFrames(t, filepath.Join(t.TempDir(), "clip.y4m"), 3)
+	header, _, _ := strings.Cut(string(data[:64]), "\n")
+	if header != "YUV4MPEG2 W160 H144 F4194304:70224 Ip A1:1 C444" {
+		t.Fatalf("cabecera %q", header)
+	}
+	frameSize := len("FRAME\n") + Width*Height*3
+	if len(data) != len(header)+1+3*frameSize {
+		t.Fatalf("tamaño %d", len(data))
+	}
+}
+
+func TestAVI(t *testing.T) {
+	data := recordFrames(t, filepath.Join(t.TempDir(), "clip.avi"), 4)
+	le := binary.LittleEndian
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "AVI " {
+		t.Fatal("cabecera RIFF inválida")
+	}
+	if int(le.Uint32(data[4:]))+8 != len(data) {
+		t.Fatalf("tamaño RIFF %d, archivo %d", le.Uint32(data[4:]), len(data))
+	}
+	if frames := le.Uint32(data[48:]); frames != 4 {
+		t.Fatalf("avih indica %d frames", frames)
+	}
+	if string(data[aviHeaderSize-4:aviHeaderSize]) != "movi" {
+		t.Fatal("falta LIST movi")
+	}
+	// Audio intercalado antes de cada frame
+	if string(data[aviHeaderSize:aviHeaderSize+4]) != "01wb" {
+		t.Fatalf("primer bloque %q", data[aviHeaderSize:aviHeaderSize+4])
+	}
+	idx := bytes.LastIndex(data, []byte("idx1"))
+	if idx < 0 || le.Uint32(data[idx+4:]) != 8*16 {
+		t.Fatal("índice inválido")
+	}
+}
+
+func TestRecorderKeepsFirstFrameSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.y4m")
+	recorder, err := CreateRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.WriteFrame(image.NewRGBA(image.Rect(0, 0, Width, Height)))
+	recorder.WriteFrame(image.NewRGBA(image.Rect(0, 0, Width*2, Height*2)))
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(path)
+	header := len("YUV4MPEG2 W160 H144 F4194304:70224 Ip A1:1 C444\n")
+	if int(info.Size()) != header+2*(6+Width*Height*3) {
+		t.Fatal("el segundo frame debería reducirse al tamaño del primero")
+	}
+}
+
+func TestCreateRecorderRejectsUnknownFormats(t *testing.T) {
+	if _, err := CreateRecorder(filepath.Join(t.TempDir(), "clip.mp4")); err == nil {
+		t.Fatal("se esperaba un error")
+	}
+}
@@ -0,0 +1,89 @@
+package video
+
+import "image"
+
+// Scale2x y Scale3x (AdvanceMAME): cada píxel se divide en 2×2 o 3×3 y las
+// esquinas toman el color de los vecinos cuando forman una diagonal, así que
+// las escaleras de píxeles se convierten en líneas. Nunca inventan colores.
+// https://www.scale2x.it/algorithm
+
+// scale2x usa los vecinos B (arriba), D (izquierda), F (derecha) y H (abajo)
+// del píxel E
+func scale2x(src, dst *image.RGBA) {
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			b, d, e := at(src, x, y-1), at(src, x-1, y), at(src, x, y)
+			f, h := at(src, x+1, y), at(src, x, y+1)
+			e0, e1, e2, e3 := e, e, e, e
+			if b != h && d != f {
+				if d == b {
+					e0 = d
+				}
+				if b == f {
+					e1 = f
+				}
+				if d == h {
+					e2 = d
+				}
+				if h == f {
+					e3 = f
+				}
+			}
+			dst.SetRGBA(x*2, y*2, e0)
+			dst.SetRGBA(x*2+1, y*2, e1)
+			dst.SetRGBA(x*2, y*2+1, e2)
+			dst.SetRGBA(x*2+1, y*2+1, e3)
+		}
+	}
+}
+
+// scale3x usa los 8 vecinos del píxel E:
+//
+//	A B C
+//	D E F
+//	G H I
+func scale3x(src, dst *image.RGBA) {
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			a, b, c := at(src, x-1, y-1), at(src, x, y-1), at(src, x+1, y-1)
+			d, e, f := at(src, x-1, y), at(src, x, y), at(src, x+1, y)
+			g, h, i := at(src, x-1, y+1), at(src, x, y+1), at(src, x+1, y+1)
+			e0, e1, e2, e3, e5, e6, e7, e8 := e, e, e, e, e, e, e, e
+			if b != h && d != f {
+				if d == b {
+					e0 = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					e1 = b
+				}
+				if b == f {
+					e2 = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					e3 = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					e5 = f
+				}
+				if d == h {
+					e6 = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					e7 = h
+				}
+				if h == f {
+					e8 = f
+				}
+			}
+			dst.SetRGBA(x*3, y*3, e0)
+			dst.SetRGBA(x*3+1, y*3, e1)
+			dst.SetRGBA(x*3+2, y*3, e2)
+			dst.SetRGBA(x*3, y*3+1, e3)
+			dst.SetRGBA(x*3+1, y*3+1, e)
+			dst.SetRGBA(x*3+2, y*3+1, e5)
+			dst.SetRGBA(x*3, y*3+2, e6)
+			dst.SetRGBA(x*3+1, y*3+2, e7)
+			dst.SetRGBA(x*3+2, y*3+2, e8)
+		}
+	}
+}
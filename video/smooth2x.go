@@ -0,0 +1,40 @@
+package video
+
+import (
+	"image"
+	"image/color"
+)
+
+// Smooth2x es una versión simplificada de HQ2x (ver hq2x.go): usa las mismas
+// comparaciones en YUV y mezclas parecidas, pero decide cada esquina solo
+// con sus tres vecinos (los dos lados y la diagonal) en lugar de la tabla de
+// 256 patrones. Suaviza los bordes con colores intermedios y es más rápido.
+
+func smooth2x(src, dst *image.RGBA) {
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			for _, corner := range [4][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}} {
+				dx, dy := corner[0], corner[1]
+				c := smoothCorner(at(src, x, y), at(src, x+dx, y), at(src, x, y+dy), at(src, x+dx, y+dy))
+				dst.SetRGBA(x*2+(dx+1)/2, y*2+(dy+1)/2, c)
+			}
+		}
+	}
+}
+
+// smoothCorner calcula el subpíxel de la esquina de center que da a los vecinos
+// horizontal, vertical y diagonal indicados
+func smoothCorner(center, horizontal, vertical, diagonal color.RGBA) color.RGBA {
+	edge := !hqDiff(horizontal, vertical) && hqDiff(center, horizontal)
+	switch {
+	case edge && !hqDiff(diagonal, horizontal):
+		// Un borde cruza la esquina: se redondea con los dos lados
+		return blend([]color.RGBA{center, horizontal, vertical}, []int{2, 1, 1})
+	case edge:
+		// Esquina interior de un borde: se suaviza menos
+		return blend([]color.RGBA{center, horizontal, vertical}, []int{6, 1, 1})
+	case hqDiff(center, diagonal):
+		return blend([]color.RGBA{center, diagonal}, []int{3, 1})
+	}
+	return center
+}
@@ -0,0 +1,159 @@
+package video
+
+import (
+	"image"
+
+	"github.com/deybismelendez/liteboy/ppu"
+)
+
+// Postproceso del framebuffer del PPU hecho en la CPU, sin depender de la
+// GPU, para que el resultado sea el mismo en la ventana y en las capturas
+// del modo headless.
+
+const (
+	Width  = ppu.ScreenWidth
+	Height = ppu.ScreenHeight
+)
+
+// Factor por el que se multiplican los píxeles que forman la grilla
+const gridDarken = 0.75
+
+// Pipeline procesa los frames del PPU: primero mezcla cada frame con los
+// anteriores para simular la respuesta lenta del LCD, luego aplica el
+// escalador elegido y por último, si se pide, dibuja la grilla de puntos de
+// la pantalla.
+type Pipeline struct {
+	// Peso (0-1) de los frames anteriores en la mezcla; 0 la desactiva. El
+	// LCD del DMG tarda varios frames en cambiar de tono, así que los juegos
+	// que hacen parpadear sprites en frames alternos se ven semitransparentes.
+	Ghosting float64
+	// Oscurece el borde de cada píxel como la separación entre los puntos
+	// del LCD. Solo se ve con Scale de 2 o más.
+	Grid bool
+	// Escalador aplicado después de la mezcla (ver Filters)
+	Filter string
+	// Escala de la imagen final; la grilla necesita al menos 2. Los
+	// escaladores ya multiplican el tamaño, así que se completa repitiendo
+	// píxeles.
+	Scale int
+
+	blended  []float32 // Frame mezclado, RGBA
+	native   *image.RGBA
+	filtered *image.RGBA
+	scaled   *image.RGBA
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		Filter:  "none",
+		Scale:   1,
+		blended: make([]float32, Width*Height*4),
+		native:  image.NewRGBA(image.Rect(0, 0, Width, Height)),
+	}
+}
+
+// Push agrega un frame RGBA de 160x144 (ppu.Framebuffer). Hay que llamarlo
+// en cada frame emulado para que la mezcla siga la velocidad de la emulación.
+func (p *Pipeline) Push(frame []byte) {
+	weight := float32(min(max(p.Ghosting, 0), 1))
+	for i, value := range frame[:len(p.blended)] {
+		p.blended[i] = p.blended[i]*weight + float32(value)*(1-weight)
+		p.native.Pix[i] = byte(p.blended[i] + 0.5)
+	}
+}
+
+// Reset descarta los frames anteriores de la mezcla
+func (p *Pipeline) Reset() {
+	clear(p.blended)
+}
+
+// Native devuelve el último frame mezclado en 160x144, sin escalar
+func (p *Pipeline) Native() *image.RGBA {
+	return p.native
+}
+
+// Image devuelve el último frame con todos los efectos aplicados. La imagen
+// se reutiliza en la siguiente llamada.
+func (p *Pipeline) Image() *image.RGBA {
+	img := p.native
+	if filter, ok := LookupFilter(p.Filter); ok {
+		p.filtered = filter.Apply(img, p.filtered)
+		img = p.filtered
+	}
+	if !p.Grid {
+		return img
+	}
+	factor := max(p.Scale*Width/img.Rect.Dx(), 1)
+	cell := img.Rect.Dx() / Width * factor
+	if cell < 2 {
+		return img
+	}
+	p.scaled = scaleNearest(img, factor, p.scaled)
+	drawGrid(p.scaled, cell)
+	return p.scaled
+}
+
+// Screenshot devuelve una copia del último frame para guardarla. Con scale 1
+// es la pantalla de 160x144 (con ghosting); con una escala mayor se aplican
+// el escalador y la grilla y se completa la escala repitiendo píxeles.
+func (p *Pipeline) Screenshot(scale int) image.Image {
+	if scale <= 1 {
+		img := image.NewRGBA(p.native.Rect)
+		copy(img.Pix, p.native.Pix)
+		return img
+	}
+	previous := p.Scale
+	p.Scale = scale
+	img := p.Image()
+	p.Scale = previous
+	return resizeNearest(img, Width*scale, Height*scale)
+}
+
+// resizeNearest cambia el tamaño de la imagen repitiendo o salteando píxeles,
+// para escalas que no son múltiplo de la del escalador
+func resizeNearest(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			i := src.PixOffset(x*src.Rect.Dx()/width, y*src.Rect.Dy()/height)
+			copy(dst.Pix[dst.PixOffset(x, y):], src.Pix[i:i+4])
+		}
+	}
+	return dst
+}
+
+// scaleNearest repite cada píxel scale veces en cada eje. dst se reutiliza
+// si tiene el tamaño correcto.
+func scaleNearest(src *image.RGBA, scale int, dst *image.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	dst = reuse(dst, bounds.Dx()*scale, bounds.Dy()*scale)
+	for y := range dst.Rect.Dy() {
+		for x := range dst.Rect.Dx() {
+			i := src.PixOffset(x/scale, y/scale)
+			copy(dst.Pix[dst.PixOffset(x, y):], src.Pix[i:i+4])
+		}
+	}
+	return dst
+}
+
+func reuse(img *image.RGBA, width, height int) *image.RGBA {
+	if img == nil || img.Rect.Dx() != width || img.Rect.Dy() != height {
+		return image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	return img
+}
+
+// drawGrid oscurece la última fila y columna de cada celda de scale×scale
+func drawGrid(img *image.RGBA, scale int) {
+	for y := range img.Rect.Dy() {
+		for x := range img.Rect.Dx() {
+			if x%scale != scale-1 && y%scale != scale-1 {
+				continue
+			}
+			i := img.PixOffset(x, y)
+			for c := range 3 {
+				img.Pix[i+c] = byte(float32(img.Pix[i+c]) * gridDarken)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package video
+
+import "testing"
+
+func solidFrame(value byte) []byte {
+	frame := make([]byte, Width*Height*4)
+	for i := range frame {
+		frame[i] = value
+	}
+	return frame
+}
+
+func TestPipelineWithoutEffects(t *testing.T) {
+	p := NewPipeline()
+	p.Push(solidFrame(0x40))
+	p.Push(solidFrame(0xC0))
+	img := p.Image()
+	if img.Rect.Dx() != Width || img.Pix[0] != 0xC0 {
+		t.Fatalf("sin efectos debería devolver el último frame, hay %02X", img.Pix[0])
+	}
+}
+
+func TestGhostingBlendsPreviousFrames(t *testing.T) {
+	p := NewPipeline()
+	p.Ghosting = 0.5
+	p.Push(solidFrame(0xFF))
+	p.Reset()
+	p.Push(solidFrame(0x00))
+	p.Push(solidFrame(0xFF))
+	if got := p.Image().Pix[0]; got != 0x80 {
+		t.Fatalf("mezcla = %02X, se esperaba 80", got)
+	}
+	// Un sprite que parpadea en frames alternos queda a mitad de tono
+	for range 30 {
+		p.Push(solidFrame(0x00))
+		p.Push(solidFrame(0xFF))
+	}
+	if got := p.Image().Pix[0]; got < 0x90 || got > 0xB0 {
+		t.Fatalf("parpadeo = %02X, se esperaba un tono intermedio", got)
+	}
+}
+
+func TestGridDarkensCellBorders(t *testing.T) {
+	p := NewPipeline()
+	p.Grid = true
+	p.Scale = 3
+	p.Push(solidFrame(0xC8))
+	img := p.Image()
+	if img.Rect.Dx() != Width*3 || img.Rect.Dy() != Height*3 {
+		t.Fatalf("tamaño %v", img.Rect)
+	}
+	if got := img.RGBAAt(0, 0).R; got != 0xC8 {
+		t.Fatalf("interior de la celda = %02X", got)
+	}
+	if got := img.RGBAAt(2, 0).R; got != 0x96 {
+		t.Fatalf("borde de la celda = %02X, se esperaba 96", got)
+	}
+	if got := img.RGBAAt(2, 0).A; got != 0xC8 {
+		t.Fatal("la grilla no debe cambiar el alfa")
+	}
+}
+
+func TestScreenshotScales(t *testing.T) {
+	p := NewPipeline()
+	p.Filter = "scale2x"
+	p.Push(solidFrame(0x40))
+	if img := p.Screenshot(1); img.Bounds().Dx() != Width || img.Bounds().Dy() != Height {
+		t.Fatalf("captura nativa de %v", img.Bounds())
+	}
+	img := p.Screenshot(3)
+	if img.Bounds().Dx() != Width*3 || img.Bounds().Dy() != Height*3 {
+		t.Fatalf("captura escalada de %v", img.Bounds())
+	}
+	if img := p.Screenshot(4); img.Bounds().Dx() != Width*4 {
+		t.Fatalf("captura escalada de %v", img.Bounds())
+	}
+	if p.Scale != 1 {
+		t.Fatal("la captura no debe cambiar la escala del pipeline")
+	}
+}
@@ -0,0 +1,108 @@
+package video
+
+import (
+	"image"
+	"image/color"
+)
+
+// 2xBR (Hyllian). Cada píxel E se divide en 4 subpíxeles que empiezan con su
+// color. Para cada esquina se compara la suma de diferencias a lo largo de
+// las dos diagonales que pasan por ella y, si el borde sigue la diagonal que
+// no contiene a E, la esquina se mezcla con el vecino más parecido. Con un
+// vecindario de 5×5 (sin las esquinas):
+//
+//	   A1 B1 C1
+//	A0 A  B  C  C4
+//	D0 D  E  F  F4
+//	G0 G  H  I  I4
+//	   G5 H5 I5
+//
+// y para la esquina inferior derecha, hay borde si
+//
+//	d(E,C)+d(E,G)+d(I,H5)+d(I,F4)+4·d(H,F) < d(H,D)+d(H,I5)+d(F,I4)+d(F,B)+4·d(E,I)
+//
+// La pendiente del borde decide cuánto se mezcla: en un borde a 45 grados la
+// esquina toma la mitad del vecino; si el borde es más horizontal (F parecido
+// a G) o más vertical (H parecido a C) la esquina toma 3/4 y el subpíxel de
+// al lado o de arriba 1/4, y si es ambas cosas 7/8 y 1/4 en los dos. Las
+// otras tres esquinas se calculan reflejando el vecindario.
+
+// xbrDistance es la distancia en YUV ponderada que usa xBR
+func xbrDistance(a, b color.RGBA) int {
+	ya, ua, va := yuv(a)
+	yb, ub, vb := yuv(b)
+	return 48*abs(ya-yb) + 7*abs(ua-ub) + 6*abs(va-vb)
+}
+
+func xbr2x(src, dst *image.RGBA) {
+	for y := range src.Rect.Dy() {
+		for x := range src.Rect.Dx() {
+			e := at(src, x, y)
+			block := [2][2]color.RGBA{{e, e}, {e, e}}
+			for _, corner := range [4][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}} {
+				xbrCorner(src, x, y, corner[0], corner[1], &block)
+			}
+			for row := range 2 {
+				for column := range 2 {
+					dst.SetRGBA(x*2+column, y*2+row, block[row][column])
+				}
+			}
+		}
+	}
+}
+
+// xbrCorner mezcla en block (fila, columna) la esquina de (x, y) en la
+// dirección (dx, dy). Los nombres son los de la esquina inferior derecha
+// (dx = dy = 1); "al lado" es el subpíxel de la misma fila y "arriba" el de
+// la misma columna.
+func xbrCorner(src *image.RGBA, x, y, dx, dy int, block *[2][2]color.RGBA) {
+	p := func(i, j int) color.RGBA { return at(src, x+i*dx, y+j*dy) }
+	e := p(0, 0)
+	b, c, d, f := p(0, -1), p(1, -1), p(-1, 0), p(1, 0)
+	g, h, i := p(-1, 1), p(0, 1), p(1, 1)
+	f4, i4, h5, i5 := p(2, 0), p(2, 1), p(0, 2), p(1, 2)
+	if e == f || e == h {
+		return
+	}
+	edge := xbrDistance(e, c) + xbrDistance(e, g) + xbrDistance(i, h5) + xbrDistance(i, f4) + 4*xbrDistance(h, f)
+	cross := xbrDistance(h, d) + xbrDistance(h, i5) + xbrDistance(f, i4) + xbrDistance(f, b) + 4*xbrDistance(e, i)
+	if edge > cross {
+		return
+	}
+	neighbor := h
+	if xbrDistance(e, f) <= xbrDistance(e, h) {
+		neighbor = f
+	}
+	cx, cy := (dx+1)/2, (dy+1)/2
+	corner, side, up := &block[cy][cx], &block[cy][1-cx], &block[1-cy][cx]
+	mix := func(dst *color.RGBA, weight int) {
+		*dst = blend([]color.RGBA{*dst, neighbor}, []int{8 - weight, weight})
+	}
+
+	// Sin un borde claro (o con las dos diagonales iguales) solo se suaviza
+	// la esquina
+	sharp := hqDiff(f, b) && hqDiff(h, d) ||
+		!hqDiff(e, i) && hqDiff(f, i4) && hqDiff(h, i5) ||
+		!hqDiff(e, g) || !hqDiff(e, c)
+	if edge == cross || !sharp {
+		mix(corner, 4)
+		return
+	}
+	ke, ki := xbrDistance(f, g), xbrDistance(h, c)
+	horizontal := 2*ke <= ki && e != g && d != g
+	vertical := ke >= 2*ki && e != c && b != c
+	switch {
+	case horizontal && vertical:
+		mix(corner, 7)
+		mix(side, 2)
+		*up = *side
+	case horizontal:
+		mix(corner, 6)
+		mix(side, 2)
+	case vertical:
+		mix(corner, 6)
+		mix(up, 2)
+	default:
+		mix(corner, 4)
+	}
+}
@@ -0,0 +1,62 @@
+package video
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+)
+
+// Y4MWriter graba video YUV 4:4:4 sin comprimir (YUV4MPEG2), que leen
+// ffmpeg y la mayoría de los editores. No lleva audio: se graba aparte en un
+// WAV con la misma duración.
+type Y4MWriter struct {
+	file   *os.File
+	w      *bufio.Writer
+	size   image.Point
+	frames int
+	planes []byte
+}
+
+func CreateY4M(path string) (*Y4MWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Y4MWriter{file: file, w: bufio.NewWriter(file)}, nil
+}
+
+func (y *Y4MWriter) WriteFrame(img image.Image) error {
+	if y.frames == 0 {
+		y.size = img.Bounds().Size()
+		y.planes = make([]byte, y.size.X*y.size.Y*3)
+		// Frecuencia exacta del DMG, progresivo, píxeles cuadrados
+		if _, err := fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444\n", y.size.X, y.size.Y, ClockRate, FrameCycles); err != nil {
+			return err
+		}
+	}
+	rgba := fixedSize(img, y.size)
+	area := y.size.X * y.size.Y
+	for i := range area {
+		r, g, b := int(rgba.Pix[i*4]), int(rgba.Pix[i*4+1]), int(rgba.Pix[i*4+2])
+		// BT.601 de rango limitado
+		y.planes[i] = byte((66*r+129*g+25*b+128)>>8 + 16)
+		y.planes[area+i] = byte((-38*r-74*g+112*b+128)>>8 + 128)
+		y.planes[2*area+i] = byte((112*r-94*g-18*b+128)>>8 + 128)
+	}
+	if _, err := y.w.WriteString("FRAME\n"); err != nil {
+		return err
+	}
+	_, err := y.w.Write(y.planes)
+	y.frames++
+	return err
+}
+
+func (y *Y4MWriter) Close() error {
+	err := y.w.Flush()
+	if y.frames == 0 {
+		err = errors.New("la grabación no tiene frames")
+	}
+	return errors.Join(err, y.file.Close())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deybismelendez/liteboy/ppu"
+)
+
+// saveVRAMImages guarda en dir los visores de VRAM como PNG (tiles, ambos
+// mapas y la OAM) y la tabla de sprites como texto
+func saveVRAMImages(p *ppu.PPU, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	images := map[string]image.Image{
+		"tiles.png":   p.TilesImage(),
+		"map9800.png": p.TileMapImage(0x9800),
+		"map9C00.png": p.TileMapImage(0x9C00),
+		"oam.png":     p.OAMImage(),
+	}
+	for name, img := range images {
+		if err := savePNG(filepath.Join(dir, name), img); err != nil {
+			return err
+		}
+	}
+	var table strings.Builder
+	for _, entry := range p.OAMEntries() {
+		fmt.Fprintln(&table, entry)
+	}
+	return os.WriteFile(filepath.Join(dir, "oam.txt"), []byte(table.String()), 0o644)
+}
+
+func savePNG(path string, img image.Image) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}